@@ -0,0 +1,261 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// AggregationExpression represents a MongoDB aggregation expression. It can be
+// used with Expr to compare fields of the same document within a query.
+//
+// Operands of an AggregationExpression can be other AggregationExpressions,
+// Fields, ArrayFields (which are used as field references like "$field") or
+// plain values.
+type AggregationExpression struct {
+	operator string
+	value    any
+}
+
+// Ref returns an aggregation expression which references the value of the field
+// ("$field").
+func (f Field) Ref() AggregationExpression {
+	return AggregationExpression{value: "$" + string(f)}
+}
+
+// Ref returns an aggregation expression which references the value of the array
+// field ("$field").
+func (f ArrayField) Ref() AggregationExpression {
+	return AggregationExpression{value: "$" + string(f)}
+}
+
+// Literal returns an aggregation expression for the given value without parsing
+// it (e.g. strings starting with "$" are not interpreted as field references).
+func Literal(value any) AggregationExpression {
+	return AggregationExpression{operator: "$literal", value: value}
+}
+
+// Cond returns an aggregation expression which evaluates to 'then' if the
+// condition is true and to 'otherwise' if not.
+func Cond(condition, then, otherwise any) AggregationExpression {
+	return AggregationExpression{operator: "$cond", value: []any{condition, then, otherwise}}
+}
+
+// IfNull returns an aggregation expression which evaluates to the given value if
+// it is not null or missing and to the replacement otherwise.
+func IfNull(value, replacement any) AggregationExpression {
+	return AggregationExpression{operator: "$ifNull", value: []any{value, replacement}}
+}
+
+// Concat returns an aggregation expression which concatenates the given strings.
+func Concat(values ...any) AggregationExpression {
+	return AggregationExpression{operator: "$concat", value: values}
+}
+
+func (ae AggregationExpression) with(operator string, operands ...any) AggregationExpression {
+	return AggregationExpression{operator: operator, value: append([]any{ae}, operands...)}
+}
+
+func (ae AggregationExpression) unary(operator string) AggregationExpression {
+	return AggregationExpression{operator: operator, value: ae}
+}
+
+// Add adds numbers (or a number of milliseconds to a date).
+func (ae AggregationExpression) Add(values ...any) AggregationExpression {
+	return ae.with("$add", values...)
+}
+
+// Subtract subtracts the given value.
+func (ae AggregationExpression) Subtract(value any) AggregationExpression {
+	return ae.with("$subtract", value)
+}
+
+// Multiply multiplies with the given values.
+func (ae AggregationExpression) Multiply(values ...any) AggregationExpression {
+	return ae.with("$multiply", values...)
+}
+
+// Divide divides by the given value.
+func (ae AggregationExpression) Divide(value any) AggregationExpression {
+	return ae.with("$divide", value)
+}
+
+// Mod returns the remainder of the division by the given value.
+func (ae AggregationExpression) Mod(value any) AggregationExpression {
+	return ae.with("$mod", value)
+}
+
+// Abs returns the absolute value.
+func (ae AggregationExpression) Abs() AggregationExpression {
+	return ae.unary("$abs")
+}
+
+// Eq is true if the values are equivalent.
+func (ae AggregationExpression) Eq(value any) AggregationExpression {
+	return ae.with("$eq", value)
+}
+
+// Ne is true if the values are not equivalent.
+func (ae AggregationExpression) Ne(value any) AggregationExpression {
+	return ae.with("$ne", value)
+}
+
+// Gt is true if the value is greater than the given value.
+func (ae AggregationExpression) Gt(value any) AggregationExpression {
+	return ae.with("$gt", value)
+}
+
+// Gte is true if the value is greater than or equal to the given value.
+func (ae AggregationExpression) Gte(value any) AggregationExpression {
+	return ae.with("$gte", value)
+}
+
+// Lt is true if the value is less than the given value.
+func (ae AggregationExpression) Lt(value any) AggregationExpression {
+	return ae.with("$lt", value)
+}
+
+// Lte is true if the value is less than or equal to the given value.
+func (ae AggregationExpression) Lte(value any) AggregationExpression {
+	return ae.with("$lte", value)
+}
+
+// Cmp returns 0 if the values are equivalent, 1 if the value is greater than the
+// given value and -1 if it is less.
+func (ae AggregationExpression) Cmp(value any) AggregationExpression {
+	return ae.with("$cmp", value)
+}
+
+// And is true if all expressions are true.
+func (ae AggregationExpression) And(values ...any) AggregationExpression {
+	return ae.with("$and", values...)
+}
+
+// Or is true if any of the expressions is true.
+func (ae AggregationExpression) Or(values ...any) AggregationExpression {
+	return ae.with("$or", values...)
+}
+
+// Not returns the opposite boolean value.
+func (ae AggregationExpression) Not() AggregationExpression {
+	return ae.with("$not")
+}
+
+// Concat concatenates the given strings.
+func (ae AggregationExpression) Concat(values ...any) AggregationExpression {
+	return ae.with("$concat", values...)
+}
+
+// ToLower converts the string to lowercase.
+func (ae AggregationExpression) ToLower() AggregationExpression {
+	return ae.unary("$toLower")
+}
+
+// ToUpper converts the string to uppercase.
+func (ae AggregationExpression) ToUpper() AggregationExpression {
+	return ae.unary("$toUpper")
+}
+
+// StrLen returns the number of UTF-8 code points in the string.
+func (ae AggregationExpression) StrLen() AggregationExpression {
+	return ae.unary("$strLenCP")
+}
+
+// Substr returns a substring which starts at the given UTF-8 code point index
+// and contains the given number of code points.
+func (ae AggregationExpression) Substr(start, length int) AggregationExpression {
+	return ae.with("$substrCP", start, length)
+}
+
+// Year returns the year of the date.
+func (ae AggregationExpression) Year() AggregationExpression {
+	return ae.unary("$year")
+}
+
+// Month returns the month (1-12) of the date.
+func (ae AggregationExpression) Month() AggregationExpression {
+	return ae.unary("$month")
+}
+
+// DayOfMonth returns the day of the month (1-31) of the date.
+func (ae AggregationExpression) DayOfMonth() AggregationExpression {
+	return ae.unary("$dayOfMonth")
+}
+
+// Hour returns the hour (0-23) of the date.
+func (ae AggregationExpression) Hour() AggregationExpression {
+	return ae.unary("$hour")
+}
+
+// DateToString converts the date to a string using the given format
+// (e.g. "%Y-%m-%d").
+func (ae AggregationExpression) DateToString(format string) AggregationExpression {
+	return AggregationExpression{operator: "$dateToString", value: bson.D{{"date", ae}, {"format", format}}}
+}
+
+// MarshalBSONValue serializes the AggregationExpression to a BSON value, so it
+// can also be used within plain bson.D documents (e.g. aggregation pipelines).
+func (ae AggregationExpression) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(ae.bson())
+}
+
+func (ae AggregationExpression) bson() any {
+	if ae.operator == "" {
+		return aggregationValue(ae.value)
+	}
+	if ae.operator == "$literal" {
+		return bson.D{{ae.operator, ae.value}}
+	}
+	return bson.D{{ae.operator, aggregationValue(ae.value)}}
+}
+
+func aggregationValue(value any) any {
+	switch value.(type) {
+	case AggregationExpression:
+		return value.(AggregationExpression).bson()
+	case FieldPath:
+		return "$" + value.(FieldPath).fieldPath()
+	case []any:
+		values := bson.A{}
+		for _, v := range value.([]any) {
+			values = append(values, aggregationValue(v))
+		}
+		return values
+	case bson.D:
+		document := bson.D{}
+		for _, e := range value.(bson.D) {
+			document = append(document, bson.E{e.Key, aggregationValue(e.Value)})
+		}
+		return document
+	}
+	return value
+}
+
+// Expr represents a query operation which allows the use of aggregation expressions
+// within the query, e.g. to compare fields of the same document.
+func Expr(expression AggregationExpression) Expression {
+	return Expression{value: expression}
+}
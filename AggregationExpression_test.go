@@ -0,0 +1,163 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+func Test_Compare_ExprCompareFields(t *testing.T) {
+
+	//given
+	f1 := Expr(Listing.ReviewsPerMonth.Ref().Gt(Listing.NumberOfReviews.Ref().Divide(12)))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$expr", bson.D{{"$gt", bson.A{
+		"$reviews_per_month",
+		bson.D{{"$divide", bson.A{"$number_of_reviews", 12}}},
+	}}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+var aggregationTestData = []struct {
+	testName  string
+	filter    Expression
+	apiFilter bson.D
+}{
+	{"fields as operands",
+		Expr(Listing.Price.Ref().Lt(Listing.CleaningFee)),
+		bson.D{{"$expr", bson.D{{"$lt", bson.A{"$price", "$cleaning_fee"}}}}},
+	},
+	{"arithmetic",
+		Expr(Listing.Price.Ref().Add(Listing.CleaningFee, 10).Multiply(2).Subtract(1).Mod(3).Abs().Gte(0)),
+		bson.D{{"$expr", bson.D{{"$gte", bson.A{
+			bson.D{{"$abs", bson.D{{"$mod", bson.A{
+				bson.D{{"$subtract", bson.A{
+					bson.D{{"$multiply", bson.A{
+						bson.D{{"$add", bson.A{"$price", "$cleaning_fee", 10}}},
+						2,
+					}}},
+					1,
+				}}},
+				3,
+			}}}}},
+			0,
+		}}}}},
+	},
+	{"boolean",
+		Expr(Listing.Bedrooms.Ref().Eq(1).Or(Listing.Bedrooms.Ref().Cmp(3).Ne(0)).And(Listing.Name.Ref().Eq("x").Not())),
+		bson.D{{"$expr", bson.D{{"$and", bson.A{
+			bson.D{{"$or", bson.A{
+				bson.D{{"$eq", bson.A{"$bedrooms", 1}}},
+				bson.D{{"$ne", bson.A{bson.D{{"$cmp", bson.A{"$bedrooms", 3}}}, 0}}},
+			}}},
+			bson.D{{"$not", bson.A{bson.D{{"$eq", bson.A{"$name", "x"}}}}}},
+		}}}}},
+	},
+	{"conditional",
+		Expr(Cond(Listing.Bedrooms.Ref().Gt(2), IfNull(Listing.CleaningFee, 0), Literal("$none")).Lte(50)),
+		bson.D{{"$expr", bson.D{{"$lte", bson.A{
+			bson.D{{"$cond", bson.A{
+				bson.D{{"$gt", bson.A{"$bedrooms", 2}}},
+				bson.D{{"$ifNull", bson.A{"$cleaning_fee", 0}}},
+				bson.D{{"$literal", "$none"}},
+			}}},
+			50,
+		}}}}},
+	},
+	{"string",
+		Expr(Listing.Name.Ref().ToLower().Substr(0, 3).Concat("-", Listing.Address.Country.Ref().ToUpper()).StrLen().Gt(5)),
+		bson.D{{"$expr", bson.D{{"$gt", bson.A{
+			bson.D{{"$strLenCP", bson.D{{"$concat", bson.A{
+				bson.D{{"$substrCP", bson.A{bson.D{{"$toLower", "$name"}}, 0, 3}}},
+				"-",
+				bson.D{{"$toUpper", "$address.country"}},
+			}}}}},
+			5,
+		}}}}},
+	},
+	{"date",
+		Expr(Listing.LastScraped.Ref().Year().Eq(2019).
+			And(Listing.LastScraped.Ref().Month().Gte(2),
+				Listing.LastScraped.Ref().DayOfMonth().Lt(15),
+				Listing.LastScraped.Ref().Hour().Eq(5),
+				Listing.LastScraped.Ref().DateToString("%Y-%m").Eq("2019-02"))),
+		bson.D{{"$expr", bson.D{{"$and", bson.A{
+			bson.D{{"$eq", bson.A{bson.D{{"$year", "$last_scraped"}}, 2019}}},
+			bson.D{{"$gte", bson.A{bson.D{{"$month", "$last_scraped"}}, 2}}},
+			bson.D{{"$lt", bson.A{bson.D{{"$dayOfMonth", "$last_scraped"}}, 15}}},
+			bson.D{{"$eq", bson.A{bson.D{{"$hour", "$last_scraped"}}, 5}}},
+			bson.D{{"$eq", bson.A{bson.D{{"$dateToString", bson.D{{"date", "$last_scraped"}, {"format", "%Y-%m"}}}}, "2019-02"}}},
+		}}}}},
+	},
+	{"expr in and condition",
+		Listing.Bedrooms.Gt(2).And(Expr(Listing.Amenities.Ref().Ne(bson.A{}))),
+		bson.D{{"$and", []bson.D{
+			{{"bedrooms", bson.D{{"$gt", 2}}}},
+			{{"$expr", bson.D{{"$ne", bson.A{"$amenities", bson.A{}}}}}},
+		}}},
+	},
+}
+
+func Test_Compare_AggregationExpressions(t *testing.T) {
+
+	for _, datum := range aggregationTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//given
+			f1 := datum.filter
+
+			//when
+			mongoFilter := f1.bsonD()
+
+			//then
+			if !reflect.DeepEqual(mongoFilter, datum.apiFilter) {
+				t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, datum.apiFilter)
+			}
+
+		})
+	}
+
+}
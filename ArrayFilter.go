@@ -0,0 +1,115 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidIdentifier is returned if the identifier of an ArrayFilter is not a
+// lowercase letter followed by letters and digits or if it is used twice.
+var ErrInvalidIdentifier = errors.New("invalid array filter identifier")
+
+var arrayFilterIdentifier = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+// Positional returns the path to the first array element which matches the query
+// (positional operator "$"), e.g. to update it with Set.
+func (f ArrayField) Positional() Field {
+	return Field(string(f) + ".$")
+}
+
+// AllPositional returns the path to all elements of the array (all positional
+// operator "$[]").
+func (f ArrayField) AllPositional() Field {
+	return Field(string(f) + ".$[]")
+}
+
+// FilteredPositional returns the path to all elements of the array which match
+// the ArrayFilter with the given identifier (filtered positional operator
+// "$[<identifier>]").
+func (f ArrayField) FilteredPositional(identifier string) Field {
+	return Field(string(f) + ".$[" + identifier + "]")
+}
+
+// ArrayFilter selects the array elements which are updated by a filtered
+// positional operator ("$[<identifier>]").
+type ArrayFilter struct {
+	identifier  string
+	expressions []Expression
+	err         error
+}
+
+// ArrayFilter returns a filter for the elements of the array which satisfy all the
+// given expressions. The fields of the expressions are either the array itself
+// (for arrays of scalar values) or fields of the embedded documents, so the fields
+// of the generated filter type for the array (e.g. 'Review.ReviewerName') can be
+// used directly. At least one expression is required.
+func (f ArrayField) ArrayFilter(identifier string, expressions ...Expression) ArrayFilter {
+	af := ArrayFilter{identifier: identifier}
+	if !arrayFilterIdentifier.MatchString(identifier) {
+		af.err = fmt.Errorf("%w: %q", ErrInvalidIdentifier, identifier)
+		return af
+	}
+	if len(expressions) == 0 {
+		af.err = &FilterError{Path: string(f), Operator: identifier, Err: ErrInvalidOperand}
+		return af
+	}
+	for _, expression := range expressions {
+		rewritten, err := expression.relativeTo(string(f), identifier)
+		if err != nil {
+			af.err = err
+			return af
+		}
+		af.expressions = append(af.expressions, rewritten)
+	}
+	return af
+}
+
+// ArrayFilters builds the array filters of the update options of the MongoDB API,
+// e.g. options.Update().SetArrayFilters(af). Each identifier may only be used once.
+func ArrayFilters(filters ...ArrayFilter) (options.ArrayFilters, error) {
+	af := options.ArrayFilters{Filters: []interface{}{}}
+	identifiers := make(map[string]bool)
+	for _, filter := range filters {
+		if filter.err != nil {
+			return options.ArrayFilters{}, filter.err
+		}
+		if identifiers[filter.identifier] {
+			return options.ArrayFilters{}, fmt.Errorf("%w: %q used more than once", ErrInvalidIdentifier, filter.identifier)
+		}
+		identifiers[filter.identifier] = true
+		for _, expression := range filter.expressions {
+			if err := expression.Validate(); err != nil {
+				return options.ArrayFilters{}, err
+			}
+		}
+		af.Filters = append(af.Filters, expressionsToDocument(filter.expressions))
+	}
+	return af, nil
+}
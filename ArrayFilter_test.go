@@ -0,0 +1,175 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleArrayFilters() {
+
+	update := Review.FilteredPositional("milo").Comments.Set("removed")
+	arrayFilters, _ := ArrayFilters(Listing.Reviews.ArrayFilter("milo", Review.ReviewerName.Equals("Milo")))
+
+	fmt.Println(update)
+	fmt.Println(arrayFilters.Filters)
+	// Output:
+	// bson.D{{"$set", bson.D{{"reviews.$[milo].comments", "removed"}}}}
+	// [[{milo.reviewer_name Milo}]]
+
+}
+
+var positionalTestData = []struct {
+	testName string
+	field    Field
+	expected string
+}{
+	{"element no", Review.ElementNo(3).Comments, "reviews.3.comments"},
+	{"positional", Review.Positional().Comments, "reviews.$.comments"},
+	{"all positional", Review.AllPositional().Comments, "reviews.$[].comments"},
+	{"filtered positional", Review.FilteredPositional("elem").Comments, "reviews.$[elem].comments"},
+	{"positional of scalar array", Listing.Amenities.Positional(), "amenities.$"},
+	{"all positional of scalar array", Listing.Amenities.AllPositional(), "amenities.$[]"},
+	{"filtered positional of scalar array", Listing.Amenities.FilteredPositional("elem"), "amenities.$[elem]"},
+}
+
+func TestPositional(t *testing.T) {
+
+	for _, datum := range positionalTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//then
+			if string(datum.field) != datum.expected {
+				t.Errorf("expected %s but got %s", datum.expected, datum.field)
+			}
+
+		})
+	}
+
+}
+
+var arrayFiltersTestData = []struct {
+	testName string
+	filters  []ArrayFilter
+	expected []bson.D
+}{
+	{
+		"scalar array",
+		[]ArrayFilter{Listing.Amenities.ArrayFilter("elem", Field(Listing.Amenities).Equals("Wifi"))},
+		[]bson.D{{{"elem", "Wifi"}}},
+	},
+	{
+		"multiple expressions",
+		[]ArrayFilter{Listing.Reviews.ArrayFilter("r", Review.ReviewerName.Equals("Milo"), Review.Date.Gte(1))},
+		[]bson.D{{{"r.reviewer_name", "Milo"}, {"r.date", bson.D{{"$gte", 1}}}}},
+	},
+	{
+		"logical operator",
+		[]ArrayFilter{Listing.Reviews.ArrayFilter("r", Review.ReviewerName.Equals("Milo").Or(Review.ReviewerName.Equals("Anna")))},
+		[]bson.D{{{"$or", []bson.D{{{"r.reviewer_name", "Milo"}}, {{"r.reviewer_name", "Anna"}}}}}},
+	},
+	{
+		"positional fields",
+		[]ArrayFilter{Listing.Reviews.ArrayFilter("r", Review.ElementNo(0).ReviewerName.Equals("Milo"), Review.FilteredPositional("x").Date.Gte(1))},
+		[]bson.D{{{"r.reviewer_name", "Milo"}, {"r.date", bson.D{{"$gte", 1}}}}},
+	},
+	{
+		"multiple filters",
+		[]ArrayFilter{
+			Listing.Reviews.ArrayFilter("r", Review.ReviewerName.Equals("Milo")),
+			Listing.Amenities.ArrayFilter("a", Field(Listing.Amenities).Ne("Wifi")),
+		},
+		[]bson.D{{{"r.reviewer_name", "Milo"}}, {{"a", bson.D{{"$ne", "Wifi"}}}}},
+	},
+}
+
+func TestArrayFilters(t *testing.T) {
+
+	for _, datum := range arrayFiltersTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			arrayFilters, err := ArrayFilters(datum.filters...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			//then
+			if len(arrayFilters.Filters) != len(datum.expected) {
+				t.Fatalf("expected %d filters but got %d", len(datum.expected), len(arrayFilters.Filters))
+			}
+			for i, filter := range arrayFilters.Filters {
+				actual, _ := bson.Marshal(filter)
+				expected, _ := bson.Marshal(datum.expected[i])
+				if !bytes.Equal(actual, expected) {
+					t.Errorf("expected %v but got %v", bson.Raw(expected), bson.Raw(actual))
+				}
+			}
+
+		})
+	}
+
+}
+
+var arrayFiltersErrorTestData = []struct {
+	testName string
+	filters  []ArrayFilter
+	expected error
+}{
+	{"invalid identifier", []ArrayFilter{Listing.Reviews.ArrayFilter("Elem", Review.ReviewerName.Equals("Milo"))}, ErrInvalidIdentifier},
+	{"duplicate identifier", []ArrayFilter{
+		Listing.Reviews.ArrayFilter("r", Review.ReviewerName.Equals("Milo")),
+		Listing.Amenities.ArrayFilter("r", Field(Listing.Amenities).Equals("Wifi")),
+	}, ErrInvalidIdentifier},
+	{"field outside of array", []ArrayFilter{Listing.Reviews.ArrayFilter("r", Listing.Name.Equals("Milo"))}, ErrInvalidFieldName},
+	{"no expressions", []ArrayFilter{Listing.Reviews.ArrayFilter("r")}, ErrInvalidOperand},
+	{"invalid expression", []ArrayFilter{Listing.Reviews.ArrayFilter("r", Review.ReviewerName.In())}, ErrInvalidOperand},
+}
+
+func TestArrayFilters_errors(t *testing.T) {
+
+	for _, datum := range arrayFiltersErrorTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := ArrayFilters(datum.filters...)
+
+			//then
+			if !errors.Is(err, datum.expected) {
+				t.Errorf("expected %v but got %v", datum.expected, err)
+			}
+
+		})
+	}
+
+}
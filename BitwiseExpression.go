@@ -0,0 +1,99 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BitMask represents the bits to check with one of the bitwise query operators.
+// It can be built from a list of bit positions, a numeric mask or a binary mask.
+type BitMask struct {
+	value any
+}
+
+// BitPositions builds a BitMask from a list of bit positions. Position 0 is the
+// least significant bit.
+func BitPositions(positions ...int) BitMask {
+	return BitMask{value: positions}
+}
+
+// NumericBitMask builds a BitMask from a non-negative numeric mask.
+func NumericBitMask(mask int64) BitMask {
+	return BitMask{value: mask}
+}
+
+// BinaryBitMask builds a BitMask from a binary mask.
+func BinaryBitMask(mask primitive.Binary) BitMask {
+	return BitMask{value: mask}
+}
+
+// BitsAllSet builds a QueryOperator for MongoDB operator "$bitsAllSet".
+// "bits all set" - all of the given bits are set
+func BitsAllSet(mask BitMask) QueryOperator {
+	return QueryOperator{operator: "$bitsAllSet", value: mask.value}
+}
+
+// BitsAnySet builds a QueryOperator for MongoDB operator "$bitsAnySet".
+// "bits any set" - any of the given bits is set
+func BitsAnySet(mask BitMask) QueryOperator {
+	return QueryOperator{operator: "$bitsAnySet", value: mask.value}
+}
+
+// BitsAllClear builds a QueryOperator for MongoDB operator "$bitsAllClear".
+// "bits all clear" - all of the given bits are clear
+func BitsAllClear(mask BitMask) QueryOperator {
+	return QueryOperator{operator: "$bitsAllClear", value: mask.value}
+}
+
+// BitsAnyClear builds a QueryOperator for MongoDB operator "$bitsAnyClear".
+// "bits any clear" - any of the given bits is clear
+func BitsAnyClear(mask BitMask) QueryOperator {
+	return QueryOperator{operator: "$bitsAnyClear", value: mask.value}
+}
+
+// BitsAllSet represents a bitwise query operation which matches documents where
+// all of the given bits of the (integer or binary) field are set.
+func (f Field) BitsAllSet(mask BitMask) Expression {
+	return Expression{field: f, value: BitsAllSet(mask)}
+}
+
+// BitsAnySet represents a bitwise query operation which matches documents where
+// any of the given bits of the (integer or binary) field is set.
+func (f Field) BitsAnySet(mask BitMask) Expression {
+	return Expression{field: f, value: BitsAnySet(mask)}
+}
+
+// BitsAllClear represents a bitwise query operation which matches documents where
+// all of the given bits of the (integer or binary) field are clear.
+func (f Field) BitsAllClear(mask BitMask) Expression {
+	return Expression{field: f, value: BitsAllClear(mask)}
+}
+
+// BitsAnyClear represents a bitwise query operation which matches documents where
+// any of the given bits of the (integer or binary) field is clear.
+func (f Field) BitsAnyClear(mask BitMask) Expression {
+	return Expression{field: f, value: BitsAnyClear(mask)}
+}
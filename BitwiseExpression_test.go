@@ -0,0 +1,119 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+)
+
+var bitwiseTestData = []struct {
+	testName  string
+	filter    Expression
+	apiFilter bson.D
+}{
+	{"bits all set with positions",
+		Listing.Bedrooms.BitsAllSet(BitPositions(0, 2)),
+		bson.D{{"bedrooms", bson.D{{"$bitsAllSet", []int{0, 2}}}}},
+	},
+	{"bits any set with numeric mask",
+		Listing.Bedrooms.BitsAnySet(NumericBitMask(5)),
+		bson.D{{"bedrooms", bson.D{{"$bitsAnySet", int64(5)}}}},
+	},
+	{"bits all clear with binary mask",
+		Listing.Bedrooms.BitsAllClear(BinaryBitMask(primitive.Binary{Data: []byte{0x05}})),
+		bson.D{{"bedrooms", bson.D{{"$bitsAllClear", primitive.Binary{Data: []byte{0x05}}}}}},
+	},
+	{"bits any clear with positions",
+		Listing.Bedrooms.BitsAnyClear(BitPositions(1)),
+		bson.D{{"bedrooms", bson.D{{"$bitsAnyClear", []int{1}}}}},
+	},
+	{"bitwise operators combined with and/or",
+		Listing.Bedrooms.BitsAllSet(BitPositions(0)).
+			And(Listing.Bedrooms.BitsAnyClear(NumericBitMask(6)).Or(Listing.Bedrooms.Gt(8))),
+		bson.D{{"$and", []bson.D{
+			{{"bedrooms", bson.D{{"$bitsAllSet", []int{0}}}}},
+			{{"$or", []bson.D{
+				{{"bedrooms", bson.D{{"$bitsAnyClear", int64(6)}}}},
+				{{"bedrooms", bson.D{{"$gt", 8}}}},
+			}}},
+		}}},
+	},
+	{"bitwise operator negated",
+		Listing.Bedrooms.Not(BitsAllSet(BitPositions(0))),
+		bson.D{{"bedrooms", bson.D{{"$not", bson.D{{"$bitsAllSet", []int{0}}}}}}},
+	},
+}
+
+func Test_Compare_BitwiseOperators(t *testing.T) {
+
+	for _, datum := range bitwiseTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//given
+			f1 := datum.filter
+
+			//when
+			mongoFilter := f1.bsonD()
+
+			//then
+			if !reflect.DeepEqual(mongoFilter, datum.apiFilter) {
+				t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, datum.apiFilter)
+			}
+
+		})
+	}
+
+}
+
+func Test_Compare_BitsAllSet(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.BitsAllSet(BitPositions(0, 1))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"bedrooms", bson.D{{"$bitsAllSet", []int{0, 1}}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
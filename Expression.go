@@ -25,9 +25,10 @@
 package filter
 
 import (
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
 )
 
 type RegexpOption string
@@ -56,12 +57,65 @@ const RegexpOptionExtended = RegexpOption("x")
 // newline characters.
 const RegexpOptionMatchAll = RegexpOption("s")
 
+// BsonType represents a BSON type which can be used with the "$type" operator.
+type BsonType string
+
+const (
+	BsonTypeDouble    = BsonType("double")
+	BsonTypeString    = BsonType("string")
+	BsonTypeObject    = BsonType("object")
+	BsonTypeArray     = BsonType("array")
+	BsonTypeBinData   = BsonType("binData")
+	BsonTypeObjectId  = BsonType("objectId")
+	BsonTypeBool      = BsonType("bool")
+	BsonTypeDate      = BsonType("date")
+	BsonTypeNull      = BsonType("null")
+	BsonTypeRegex     = BsonType("regex")
+	BsonTypeInt       = BsonType("int")
+	BsonTypeTimestamp = BsonType("timestamp")
+	BsonTypeLong      = BsonType("long")
+	BsonTypeDecimal   = BsonType("decimal")
+)
+
+// BsonTypeNumber is an alias which matches all numeric BSON types ("double",
+// "int", "long" and "decimal").
+const BsonTypeNumber = BsonType("number")
+
+// Inclusivity defines which bounds of a range are included.
+type Inclusivity int
+
+const (
+	// IncludeLower includes the lower bound and excludes the upper bound ("$gte", "$lt").
+	IncludeLower Inclusivity = iota
+	// IncludeUpper excludes the lower bound and includes the upper bound ("$gt", "$lte").
+	IncludeUpper
+	// IncludeBoth includes both bounds ("$gte", "$lte").
+	IncludeBoth
+	// IncludeNone excludes both bounds ("$gt", "$lt").
+	IncludeNone
+)
+
 // Field represents a single field in a BSON document.
 type Field string
 
 // ArrayField represents an array field in a BSON document.
 type ArrayField string
 
+// FieldPath is implemented by all field types (Field, ArrayField, TypedField and
+// TypedArrayField). It is used where the kind of the field does not matter, e.g.
+// for projections.
+type FieldPath interface {
+	fieldPath() string
+}
+
+func (f Field) fieldPath() string {
+	return string(f)
+}
+
+func (f ArrayField) fieldPath() string {
+	return string(f)
+}
+
 // QueryOperator is used to represent a MongoDB Query Operator.
 type QueryOperator struct {
 	operator string
@@ -69,7 +123,17 @@ type QueryOperator struct {
 }
 
 func (qo QueryOperator) bson() bson.D {
-	return bson.D{{qo.operator, qo.value}}
+	return bson.D{{qo.operator, qo.bsonValue()}}
+}
+
+func (qo QueryOperator) bsonValue() any {
+	switch qo.value.(type) {
+	case []QueryOperator:
+		return queryOperatorsToBSON(qo.value.([]QueryOperator))
+	case []Expression:
+		return expressionsToDocument(qo.value.([]Expression))
+	}
+	return qo.value
 }
 
 // Equals builds a simple QueryOperator for MongoDB operator "$eq".
@@ -118,6 +182,13 @@ func NotIn(value ...any) QueryOperator {
 	return QueryOperator{operator: "$nin", value: value}
 }
 
+// Mod builds a simple QueryOperator for MongoDB operator "$mod".
+// "modulo" - value divided by divisor has the specified remainder. A divisor of
+// 0 is reported as ErrInvalidOperand.
+func Mod(divisor, remainder any) QueryOperator {
+	return QueryOperator{operator: "$mod", value: []any{divisor, remainder}}
+}
+
 // Exists builds a simple QueryOperator for MongoDB operator "$exists".
 // "exists"
 func Exists() QueryOperator {
@@ -130,6 +201,19 @@ func NotExists() QueryOperator {
 	return QueryOperator{operator: "$exists", value: false}
 }
 
+// Type builds a simple QueryOperator for MongoDB operator "$type".
+// "type" - matches if the value is of (one of) the given BSON type(s)
+func Type(types ...BsonType) QueryOperator {
+	if len(types) == 1 {
+		return QueryOperator{operator: "$type", value: string(types[0])}
+	}
+	values := make([]string, 0, len(types))
+	for _, t := range types {
+		values = append(values, string(t))
+	}
+	return QueryOperator{operator: "$type", value: values}
+}
+
 // All builds a simple QueryOperator for MongoDB operator "$all".
 // "all"
 func All(val ...any) QueryOperator {
@@ -148,6 +232,22 @@ func Regex(val string) QueryOperator {
 	return QueryOperator{operator: "$regex", value: val}
 }
 
+// Not builds a QueryOperator for MongoDB operator "$not".
+// "not" - inverts the effect of the given operator(s)
+func Not(operators ...QueryOperator) QueryOperator {
+	return QueryOperator{operator: "$not", value: operators}
+}
+
+// ElemMatch builds a QueryOperator for MongoDB operator "$elemMatch".
+// "element match" - at least one array element matches all given operators
+func ElemMatch(operators ...QueryOperator) QueryOperator {
+	return QueryOperator{operator: "$elemMatch", value: operators}
+}
+
+func elemMatchExpressions(expressions []Expression) QueryOperator {
+	return QueryOperator{operator: "$elemMatch", value: expressions}
+}
+
 func regexOptionAsQueryOperator(val string) QueryOperator {
 	return QueryOperator{operator: "$options", value: val}
 }
@@ -162,8 +262,13 @@ type LogicalOperator struct {
 type Expression struct {
 	field Field
 	value any
+	err   error
 }
 
+// ErrDuplicateOperator is returned if the same operator is used more than once
+// for a single field.
+var ErrDuplicateOperator = errors.New("duplicate operator")
+
 // Equals represents a query operation for 'equals' comparison.
 func (f Field) Equals(value any) Expression {
 	return Expression{field: f, value: value}
@@ -219,6 +324,48 @@ func (f Field) GreaterThanOrEquals(value any) Expression {
 	return f.Gte(value)
 }
 
+// Where represents a query operation which combines multiple operators for the
+// field in a single operator document, e.g. Where(Gt(1), Lt(5), Ne(3)). Each
+// operator may only be used once - otherwise marshalling the Expression fails
+// with ErrDuplicateOperator. At least one operator is required.
+func (f Field) Where(operators ...QueryOperator) Expression {
+	if len(operators) == 0 {
+		return Expression{field: f, value: operators,
+			err: fmt.Errorf("%w: no operators given for field %q", ErrInvalidOperand, f)}
+	}
+	seen := make(map[string]bool)
+	for _, operator := range operators {
+		if seen[operator.operator] {
+			return Expression{field: f, value: operators,
+				err: fmt.Errorf("%w: %s used more than once for field %q", ErrDuplicateOperator, operator.operator, f)}
+		}
+		seen[operator.operator] = true
+	}
+	return Expression{field: f, value: operators}
+}
+
+// Between represents a query operation for a range comparison. The lower and
+// upper bound are combined in a single operator document for the field. The
+// given Inclusivity defines which bounds are part of the range.
+func (f Field) Between(lower, upper any, inclusivity Inclusivity) Expression {
+	switch inclusivity {
+	case IncludeUpper:
+		return f.Where(Gt(lower), Lte(upper))
+	case IncludeBoth:
+		return f.Where(Gte(lower), Lte(upper))
+	case IncludeNone:
+		return f.Where(Gt(lower), Lt(upper))
+	default:
+		return f.Where(Gte(lower), Lt(upper))
+	}
+}
+
+// Mod represents a query operation which selects the documents where the value of
+// the field divided by a divisor has the specified remainder.
+func (f Field) Mod(divisor, remainder any) Expression {
+	return Expression{field: f, value: Mod(divisor, remainder)}
+}
+
 // In represents a query operation for 'in' comparison. The operator selects
 // the documents where the value of a field equals any value in the specified parameter(s).
 func (f Field) In(value ...any) Expression {
@@ -245,6 +392,18 @@ func (f Field) NotExists() Expression {
 	return Expression{field: f, value: NotExists()}
 }
 
+// Type represents an element query operation to check the BSON type of a field. It
+// Matches documents where the field is of (one of) the specified BSON type(s).
+func (f Field) Type(types ...BsonType) Expression {
+	return Expression{field: f, value: Type(types...)}
+}
+
+// NotType represents an element query operation to check the BSON type of a field. It
+// Matches documents where the field is not of the specified BSON type(s).
+func (f Field) NotType(types ...BsonType) Expression {
+	return Expression{field: f, value: Not(Type(types...))}
+}
+
 // Regex represents an element query operation which has regular expression capabilities
 // for pattern matching strings in queries.
 func (f Field) Regex(val string, opts ...RegexpOption) Expression {
@@ -261,6 +420,13 @@ func (f Field) Regex(val string, opts ...RegexpOption) Expression {
 	return Expression{field: f, value: combinedValue}
 }
 
+// Not represents a logical query operation which selects the documents that
+// do not match the given operator(s). This includes documents that do not
+// contain the field.
+func (f Field) Not(operators ...QueryOperator) Expression {
+	return Expression{field: f, value: Not(operators...)}
+}
+
 // ArrayContainsAll matches all documents where the given values are in the array.
 func (f ArrayField) ArrayContainsAll(val ...any) Expression {
 	return Expression{field: Field(f), value: All(val...)}
@@ -271,6 +437,9 @@ func (f ArrayField) ArrayContainsExact(val ...any) Expression {
 	return Expression{field: Field(f), value: val}
 }
 
+// ArrayContainsElement matches all documents where the array satisfies the given
+// operators. Each operator may be satisfied by a different element - use
+// ArrayElemMatch if one element has to satisfy all of them.
 func (f ArrayField) ArrayContainsElement(queries ...QueryOperator) Expression {
 	return Expression{field: Field(f), value: queries}
 }
@@ -280,6 +449,30 @@ func (f ArrayField) ArrayContainsElementMatchesExpression(expressions ...Express
 	return Expression{field: Field(f), value: expressions}
 }
 
+// ArrayElemMatch matches all documents where at least one element of an array
+// of scalar values satisfies all the given operators.
+func (f ArrayField) ArrayElemMatch(queries ...QueryOperator) Expression {
+	return Expression{field: Field(f), value: ElemMatch(queries...)}
+}
+
+// ArrayElemMatchExpression matches all documents where at least one embedded document
+// of the array satisfies all the given expressions. Fields of the expressions are
+// used relative to the array element, so the fields of the generated filter type
+// for the array (e.g. 'Reviews.ReviewerName') can be used directly. Positional
+// segments (e.g. 'Review.ElementNo(0).ReviewerName') are removed; fields outside
+// of the array are reported as FilterError.
+func (f ArrayField) ArrayElemMatchExpression(expressions ...Expression) Expression {
+	var relative []Expression
+	for _, expression := range expressions {
+		rewritten, err := expression.relativeTo(string(f), "")
+		if err != nil {
+			return Expression{field: Field(f), value: elemMatchExpressions(expressions), err: err}
+		}
+		relative = append(relative, rewritten)
+	}
+	return Expression{field: Field(f), value: elemMatchExpressions(relative)}
+}
+
 func (f ArrayField) ArraySize(size int) Expression {
 	return Expression{field: Field(f), value: Size(size)}
 }
@@ -302,57 +495,126 @@ func (e Expression) Or(e2 ...Expression) Expression {
 	return Expression{value: LogicalOperator{operator: "$or", expressions: all}}
 }
 
-func (e Expression) String() string {
+// Nor represents a logical query operation for 'nor' condition. It takes one or more
+// Expression(s) and selects the documents that fail all the expressions.
+func (e Expression) Nor(e2 ...Expression) Expression {
+	var all []Expression
+	all = append(all, e)
+	all = append(all, e2...)
+	return Expression{value: LogicalOperator{operator: "$nor", expressions: all}}
+}
 
-	data := e.bsonD()
-	bytes, _ := bson.Marshal(data)
-	mapdata := make(map[string]any)
-	_ = bson.Unmarshal(bytes, &mapdata)
-
-	return mapToString(mapdata)
-}
-
-func mapToString(mapdata map[string]any) string {
-	returnVal := "bson.D{"
-	seperator := ""
-	for k, v := range mapdata {
-		returnVal += seperator
-		seperator = ","
-		switch v.(type) {
-		case map[string]any:
-			returnVal += fmt.Sprintf("{\"%s\", %v}", k, mapToString(v.(map[string]any)))
-		case primitive.A:
-			value := v.(primitive.A)
-			returnVal += fmt.Sprintf("{\"%s\", ", k)
-			separator := ""
-			returnVal += "[]bson.D{"
-			for _, v = range value {
-				returnVal += separator
-				separator = ", "
-				if v1, ok := v.(map[string]any); ok {
-					returnVal += fmt.Sprintf("%s", mapToString(v1))
-				} else {
-					returnVal += fmt.Sprintf("{\"%s\", %v}", k, v)
-				}
+// relativeTo rewrites all fields of the expression relative to an element of the
+// given array and prepends the prefix (if not empty). Fields outside of the array
+// are reported as FilterError.
+func (e Expression) relativeTo(array, prefix string) (Expression, error) {
+	node, err := relativeNode(e.Node(), array, prefix)
+	if err != nil {
+		return e, err
+	}
+	return node.Expression(), nil
+}
+
+// relativeNode rewrites the paths of all field nodes. Nodes within operators
+// (e.g. "$elemMatch") are already relative and therefore not changed.
+func relativeNode(node Node, array, prefix string) (Node, error) {
+	switch node.Kind {
+	case NodeLogical:
+		children := make([]Node, 0, len(node.Children))
+		for _, child := range node.Children {
+			rewritten, err := relativeNode(child, array, prefix)
+			if err != nil {
+				return node, err
 			}
-			returnVal += "}}"
-		case int, int32, int64, float32, float64:
-			returnVal += fmt.Sprintf("{\"%s\", %v}", k, v)
-		default:
-			returnVal += fmt.Sprintf("{\"%s\", \"%v\"}", k, v)
+			children = append(children, rewritten)
+		}
+		node.Children = children
+	case NodeField:
+		relative, err := relativePath(array, string(node.Field))
+		if err != nil {
+			return node, err
+		}
+		path := prefix
+		switch {
+		case path == "":
+			path = relative
+		case relative != "":
+			path += "." + relative
+		}
+		if path == "" {
+			return node, &FilterError{Path: string(node.Field), Err: ErrInvalidFieldName}
+		}
+		node.Field = Field(path)
+	}
+	return node, nil
+}
+
+// relativePath returns the path relative to an element of the array. A positional
+// segment directly following the array (an index, "$", "$[]" or "$[<identifier>]")
+// is removed, so "reviews.0.reviewer_name" becomes "reviewer_name".
+func relativePath(array, path string) (string, error) {
+	if path != array && !strings.HasPrefix(path, array+".") {
+		return "", &FilterError{Path: path, Err: ErrInvalidFieldName}
+	}
+	relative := strings.TrimPrefix(strings.TrimPrefix(path, array), ".")
+	if segment, rest, _ := strings.Cut(relative, "."); positionalSegment(segment) {
+		relative = rest
+	}
+	return relative, nil
+}
+
+func positionalSegment(segment string) bool {
+	if segment == "$" || strings.HasPrefix(segment, "$[") && strings.HasSuffix(segment, "]") {
+		return true
+	}
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
 		}
 	}
-	returnVal += "}"
+	return true
+}
 
-	return returnVal
+// String returns the Expression as Go bson.D literal (see StyleGo).
+func (e Expression) String() string {
+	return e.Render(StyleGo)
 }
 
 // MarshalBSON serializes the Expression to BSON data.
 func (e Expression) MarshalBSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
 	data := e.bsonD()
 	return bson.Marshal(data)
 }
 
+// buildError returns the first error which occurred while building the expression
+// or one of its nested expressions.
+func (e Expression) buildError() error {
+	if e.err != nil {
+		return e.err
+	}
+	var nested []Expression
+	switch e.value.(type) {
+	case LogicalOperator:
+		nested = e.value.(LogicalOperator).expressions
+	case []Expression:
+		nested = e.value.([]Expression)
+	case QueryOperator:
+		nested, _ = e.value.(QueryOperator).value.([]Expression)
+	}
+	for _, expression := range nested {
+		if err := expression.buildError(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (e Expression) bsonD() bson.D {
 
 	var returnValue bson.D
@@ -366,6 +628,18 @@ func (e Expression) bsonD() bson.D {
 	case QueryOperator:
 		qo := e.value.(QueryOperator)
 		returnValue = bson.D{{string(e.field), qo.bson()}}
+	case []Expression:
+		expressions := e.value.([]Expression)
+		returnValue = bson.D{{string(e.field), expressionsToBSON(expressions)}}
+	case textSearch:
+		ts := e.value.(textSearch)
+		returnValue = ts.bson()
+	case AggregationExpression:
+		ae := e.value.(AggregationExpression)
+		returnValue = bson.D{{"$expr", ae.bson()}}
+	case Schema:
+		schema := e.value.(Schema)
+		returnValue = bson.D{{"$jsonSchema", schema.bson()}}
 	default:
 		returnValue = bson.D{{string(e.field), e.value}}
 	}
@@ -376,26 +650,32 @@ func (e Expression) bsonD() bson.D {
 func queryOperatorsToBSON(operators []QueryOperator) bson.D {
 	value := bson.D{}
 	for _, operator := range operators {
-		value = append(value, bson.E{operator.operator, operator.value})
+		value = append(value, bson.E{operator.operator, operator.bsonValue()})
 	}
 	return value
 }
 
+// expressionsToDocument combines the given expressions to a single document. If
+// the expressions use the same key more than once they are combined with "$and".
+func expressionsToDocument(expressions []Expression) bson.D {
+	document := bson.D{}
+	keys := make(map[string]bool)
+	for _, expression := range expressions {
+		for _, element := range expression.bsonD() {
+			if keys[element.Key] {
+				return bson.D{{"$and", expressionsToBSON(expressions)}}
+			}
+			keys[element.Key] = true
+			document = append(document, element)
+		}
+	}
+	return document
+}
+
 func expressionsToBSON(expressions []Expression) []bson.D {
 	values := []bson.D{}
 	for _, expression := range expressions {
-		var d bson.D
-		switch expression.value.(type) {
-		case QueryOperator:
-			qo := expression.value.(QueryOperator)
-			d = bson.D{primitive.E{Key: string(expression.field), Value: bson.D{{Key: qo.operator, Value: qo.value}}}}
-		case []QueryOperator:
-			qo := expression.value.([]QueryOperator)
-			d = bson.D{primitive.E{Key: string(expression.field), Value: queryOperatorsToBSON(qo)}}
-		default:
-			d = bson.D{primitive.E{Key: string(expression.field), Value: expression.value}}
-		}
-		values = append(values, d)
+		values = append(values, expression.bsonD())
 	}
 	return values
 }
@@ -0,0 +1,870 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotSupported is returned if an expression contains an operator which can
+// not be evaluated in memory (e.g. "$text", "$expr" or geospatial operators).
+var ErrNotSupported = errors.New("operator not supported for in-memory evaluation")
+
+// Matches reports whether the given document matches the Expression. The
+// document can be a bson.D, a bson.M, a bson.Raw or a (bson tagged) Go struct.
+//
+// The evaluation follows the semantics of MongoDB queries, e.g. values of
+// different numeric types are compared by their value, dotted paths traverse
+// arrays and a condition on an array field matches if the array itself or any
+// of its elements matches. Text search, aggregation expressions, JSON schema and
+// geospatial operators are not supported (ErrNotSupported). Strings are compared
+// binary (no collation).
+func (e Expression) Matches(document any) (bool, error) {
+	filterData, err := bson.Marshal(e)
+	if err != nil {
+		return false, err
+	}
+	var filter bson.D
+	if err = bson.Unmarshal(filterData, &filter); err != nil {
+		return false, err
+	}
+
+	doc, err := normalizeDocument(document)
+	if err != nil {
+		return false, err
+	}
+
+	return matchDocument(doc, filter)
+}
+
+func normalizeDocument(document any) (bson.D, error) {
+	var data []byte
+	switch document.(type) {
+	case bson.Raw:
+		data = document.(bson.Raw)
+	case []byte:
+		data = document.([]byte)
+	default:
+		var err error
+		data, err = bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var doc bson.D
+	err := bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+func matchDocument(doc bson.D, filter bson.D) (bool, error) {
+	for _, element := range filter {
+		matched, err := matchElement(doc, element)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchElement(doc bson.D, element bson.E) (bool, error) {
+	switch element.Key {
+	case "$and", "$or", "$nor":
+		filters, _ := toSlice(element.Value)
+		for _, f := range filters {
+			subFilter, _ := toDocument(f)
+			matched, err := matchDocument(doc, subFilter)
+			if err != nil {
+				return false, err
+			}
+			switch {
+			case element.Key == "$and" && !matched:
+				return false, nil
+			case element.Key == "$or" && matched:
+				return true, nil
+			case element.Key == "$nor" && matched:
+				return false, nil
+			}
+		}
+		return element.Key != "$or", nil
+	}
+
+	if strings.HasPrefix(element.Key, "$") {
+		return false, &FilterError{Operator: element.Key, Err: ErrNotSupported}
+	}
+
+	values, missing := lookup(doc, strings.Split(element.Key, "."))
+	operators, ok := toDocument(element.Value)
+	if !ok || !isOperatorDocument(operators) {
+		return matchEquals(values, missing, element.Value, true), nil
+	}
+	return matchOperators(element.Key, values, missing, operators)
+}
+
+// lookup returns all values the path resolves to. Arrays on the path are
+// traversed. missing reports if the path could not be resolved for at least one
+// branch.
+func lookup(value any, path []string) (values []any, missing bool) {
+	if len(path) == 0 {
+		return []any{value}, false
+	}
+	switch value.(type) {
+	case bson.D:
+		for _, e := range value.(bson.D) {
+			if e.Key == path[0] {
+				return lookup(e.Value, path[1:])
+			}
+		}
+		return nil, true
+	case bson.A:
+		array := value.(bson.A)
+		if index, err := strconv.Atoi(path[0]); err == nil {
+			if index >= 0 && index < len(array) {
+				return lookup(array[index], path[1:])
+			}
+			return nil, true
+		}
+		missing = len(array) == 0
+		for _, element := range array {
+			if _, isDoc := element.(bson.D); !isDoc {
+				continue
+			}
+			elementValues, elementMissing := lookup(element, path)
+			values = append(values, elementValues...)
+			missing = missing || elementMissing
+		}
+		return values, missing
+	}
+	return nil, true
+}
+
+// anyCandidate reports if the predicate is true for any value or any element of
+// an array value.
+func anyCandidate(values []any, predicate func(any) bool) bool {
+	for _, value := range values {
+		if predicate(value) {
+			return true
+		}
+		if array, ok := value.(bson.A); ok {
+			for _, element := range array {
+				if predicate(element) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// matchEquals reports if one of the values equals the expected value. If
+// patterns is true (implicit equality, "$in", "$nin" and "$all") a regular
+// expression matches all strings with the pattern, otherwise ("$eq" and "$ne")
+// only an equal stored regular expression.
+func matchEquals(values []any, missing bool, expected any, patterns bool) bool {
+	if expected == nil && (missing || len(values) == 0) {
+		return true
+	}
+	return anyCandidate(values, func(value any) bool {
+		return equalsValue(value, expected, patterns)
+	})
+}
+
+func equalsValue(value, expected any, patterns bool) bool {
+	if regex, ok := expected.(primitive.Regex); ok && patterns {
+		if s, isString := value.(string); isString {
+			matched, _ := matchRegex(s, regex.Pattern, regex.Options)
+			return matched
+		}
+	}
+	if typeBracket(value) != typeBracket(expected) {
+		return false
+	}
+	return compareValues(value, expected) == 0
+}
+
+func matchOperators(path string, values []any, missing bool, operators bson.D) (bool, error) {
+	options := ""
+	for _, operator := range operators {
+		if operator.Key == "$options" {
+			options, _ = operator.Value.(string)
+		}
+	}
+
+	for _, operator := range operators {
+		matched, err := matchOperator(path, values, missing, operator, options)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(path string, values []any, missing bool, operator bson.E, options string) (bool, error) {
+	switch operator.Key {
+	case "$eq":
+		return matchEquals(values, missing, operator.Value, false), nil
+	case "$ne":
+		return !matchEquals(values, missing, operator.Value, false), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		if typeBracket(operator.Value) == 1 {
+			// null bounds compare like equality, missing fields are null
+			inclusive := operator.Key == "$gte" || operator.Key == "$lte"
+			return inclusive && matchEquals(values, missing, nil, false), nil
+		}
+		return anyCandidate(values, func(value any) bool {
+			if typeBracket(value) != typeBracket(operator.Value) {
+				return false
+			}
+			c := compareValues(value, operator.Value)
+			switch operator.Key {
+			case "$gt":
+				return c > 0
+			case "$gte":
+				return c >= 0
+			case "$lt":
+				return c < 0
+			}
+			return c <= 0
+		}), nil
+	case "$in", "$nin":
+		expected, _ := toSlice(operator.Value)
+		matched := false
+		for _, e := range expected {
+			if matchEquals(values, missing, e, true) {
+				matched = true
+				break
+			}
+		}
+		return matched == (operator.Key == "$in"), nil
+	case "$exists":
+		exists := len(values) > 0
+		return exists == truthy(operator.Value), nil
+	case "$size":
+		size, _ := toInt(operator.Value)
+		for _, value := range values {
+			if array, ok := value.(bson.A); ok && len(array) == size {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$all":
+		expected, _ := toSlice(operator.Value)
+		if len(expected) == 0 {
+			return false, nil
+		}
+		for _, e := range expected {
+			if subFilter, ok := toDocument(e); ok && len(subFilter) > 0 && subFilter[0].Key == "$elemMatch" {
+				matched, err := matchOperator(path, values, missing, subFilter[0], "")
+				if err != nil || !matched {
+					return false, err
+				}
+				continue
+			}
+			if !matchEquals(values, missing, e, true) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$regex":
+		pattern, regexOptions := "", options
+		switch operator.Value.(type) {
+		case string:
+			pattern = operator.Value.(string)
+		case primitive.Regex:
+			regex := operator.Value.(primitive.Regex)
+			pattern = regex.Pattern
+			if regexOptions == "" {
+				regexOptions = regex.Options
+			}
+		}
+		re, err := compileRegex(pattern, regexOptions)
+		if err != nil {
+			return false, err
+		}
+		return anyCandidate(values, func(value any) bool {
+			s, ok := value.(string)
+			return ok && re.MatchString(s)
+		}), nil
+	case "$options":
+		return true, nil
+	case "$not":
+		if regex, ok := operator.Value.(primitive.Regex); ok {
+			return !matchEquals(values, missing, regex, true), nil
+		}
+		inner, _ := toDocument(operator.Value)
+		matched, err := matchOperators(path, values, missing, inner)
+		return !matched, err
+	case "$type":
+		types, ok := toSlice(operator.Value)
+		if !ok {
+			types = []any{operator.Value}
+		}
+		for _, value := range values {
+			for _, t := range types {
+				if hasType(value, t) {
+					return true, nil
+				}
+			}
+			if array, isArray := value.(bson.A); isArray {
+				for _, element := range array {
+					for _, t := range types {
+						if hasType(element, t) {
+							return true, nil
+						}
+					}
+				}
+			}
+		}
+		return false, nil
+	case "$mod":
+		operands, _ := toSlice(operator.Value)
+		if len(operands) != 2 {
+			return false, &FilterError{Path: path, Operator: operator.Key, Err: ErrInvalidOperand}
+		}
+		divisor, remainder := int64(toFloat(operands[0])), int64(toFloat(operands[1]))
+		if divisor == 0 {
+			return false, &FilterError{Path: path, Operator: operator.Key, Err: ErrInvalidOperand}
+		}
+		return anyCandidate(values, func(value any) bool {
+			return typeBracket(value) == 2 && int64(toFloat(value))%divisor == remainder
+		}), nil
+	case "$elemMatch":
+		subFilter, _ := toDocument(operator.Value)
+		for _, value := range values {
+			array, ok := value.(bson.A)
+			if !ok {
+				continue
+			}
+			for _, element := range array {
+				matched, err := matchElemMatch(path, element, subFilter)
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case "$bitsAllSet", "$bitsAnySet", "$bitsAllClear", "$bitsAnyClear":
+		positions, ok := bitPositions(operator.Value)
+		if !ok {
+			return false, &FilterError{Path: path, Operator: operator.Key, Err: ErrInvalidOperand}
+		}
+		return anyCandidate(values, func(value any) bool {
+			number, ok := toInt64(value)
+			if !ok {
+				return false
+			}
+			set := 0
+			for _, position := range positions {
+				if bitSet(number, position) {
+					set++
+				}
+			}
+			switch operator.Key {
+			case "$bitsAllSet":
+				return set == len(positions)
+			case "$bitsAnySet":
+				return set > 0
+			case "$bitsAllClear":
+				return set == 0
+			}
+			return set < len(positions)
+		}), nil
+	}
+	return false, &FilterError{Path: path, Operator: operator.Key, Err: ErrNotSupported}
+}
+
+func matchElemMatch(path string, element any, subFilter bson.D) (bool, error) {
+	if isOperatorDocument(subFilter) && !isLogicalOperator(subFilter[0].Key) {
+		// operators are applied to the element itself (without traversing arrays)
+		if array, isArray := element.(bson.A); isArray {
+			element = bson.D{{"v", array}}
+			values, _ := lookup(element, []string{"v"})
+			return matchOperators(path, values, false, subFilter)
+		}
+		return matchOperators(path, []any{element}, false, subFilter)
+	}
+	doc, ok := element.(bson.D)
+	if !ok {
+		return false, nil
+	}
+	return matchDocument(doc, subFilter)
+}
+
+func truthy(value any) bool {
+	switch value.(type) {
+	case bool:
+		return value.(bool)
+	case nil:
+		return false
+	}
+	if typeBracket(value) == 2 {
+		return toFloat(value) != 0
+	}
+	return true
+}
+
+// bitPositions returns the positions of the bits of a bit mask (a list of
+// positions, a binary mask of any length or a non-negative number).
+func bitPositions(value any) ([]int, bool) {
+	if list, ok := toSlice(value); ok {
+		var positions []int
+		for _, position := range list {
+			p, ok := toInt64(position)
+			if !ok || p < 0 {
+				return nil, false
+			}
+			positions = append(positions, int(p))
+		}
+		return positions, true
+	}
+	if binary, ok := value.(primitive.Binary); ok {
+		var positions []int
+		for i, b := range binary.Data {
+			for j := 0; j < 8; j++ {
+				if b&(1<<uint(j)) != 0 {
+					positions = append(positions, 8*i+j)
+				}
+			}
+		}
+		return positions, true
+	}
+	if mask, ok := toInt64(value); ok && mask >= 0 {
+		var positions []int
+		for j := 0; j < 63; j++ {
+			if mask&(1<<uint(j)) != 0 {
+				positions = append(positions, j)
+			}
+		}
+		return positions, true
+	}
+	return nil, false
+}
+
+// bitSet reports if the bit at the given position of the two's complement of
+// the number is set. Like MongoDB positions beyond 63 are sign extended.
+func bitSet(number int64, position int) bool {
+	if position >= 64 {
+		return number < 0
+	}
+	return number&(1<<uint(position)) != 0
+}
+
+// toInt64 returns the value of integral numbers which can be represented as
+// int64. Doubles and decimals with a fractional part are not converted.
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case float32, float64, primitive.Decimal128:
+		r, ok := toRat(v)
+		if !ok || !r.IsInt() || !r.Num().IsInt64() {
+			return 0, false
+		}
+		return r.Num().Int64(), true
+	}
+	return 0, false
+}
+
+var bsonTypeNumbers = map[int]BsonType{
+	1: BsonTypeDouble, 2: BsonTypeString, 3: BsonTypeObject, 4: BsonTypeArray,
+	5: BsonTypeBinData, 7: BsonTypeObjectId, 8: BsonTypeBool, 9: BsonTypeDate,
+	10: BsonTypeNull, 11: BsonTypeRegex, 16: BsonTypeInt, 17: BsonTypeTimestamp,
+	18: BsonTypeLong, 19: BsonTypeDecimal,
+}
+
+func hasType(value any, t any) bool {
+	name, ok := t.(string)
+	if !ok {
+		number, isNumber := toInt(t)
+		if !isNumber {
+			return false
+		}
+		name = string(bsonTypeNumbers[number])
+	}
+
+	switch BsonType(name) {
+	case BsonTypeNumber:
+		return typeBracket(value) == 2
+	case BsonTypeDouble:
+		_, ok = value.(float64)
+	case BsonTypeString:
+		_, ok = value.(string)
+	case BsonTypeObject:
+		_, ok = value.(bson.D)
+	case BsonTypeArray:
+		_, ok = value.(bson.A)
+	case BsonTypeBinData:
+		_, ok = value.(primitive.Binary)
+	case BsonTypeObjectId:
+		_, ok = value.(primitive.ObjectID)
+	case BsonTypeBool:
+		_, ok = value.(bool)
+	case BsonTypeDate:
+		_, ok = value.(primitive.DateTime)
+	case BsonTypeNull:
+		ok = value == nil
+	case BsonTypeRegex:
+		_, ok = value.(primitive.Regex)
+	case BsonTypeInt:
+		_, ok = value.(int32)
+	case BsonTypeTimestamp:
+		_, ok = value.(primitive.Timestamp)
+	case BsonTypeLong:
+		_, ok = value.(int64)
+	case BsonTypeDecimal:
+		_, ok = value.(primitive.Decimal128)
+	default:
+		ok = false
+	}
+	return ok
+}
+
+// typeBracket returns the position of the type of the value in the BSON
+// comparison order. All numeric types share the same position.
+func typeBracket(value any) int {
+	switch value.(type) {
+	case primitive.MinKey:
+		return 0
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64, primitive.Decimal128:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.D, bson.M:
+		return 4
+	case bson.A, []any:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime, time.Time:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	case primitive.MaxKey:
+		return 13
+	}
+	return 12
+}
+
+// compareValues compares two values using the BSON comparison order. It returns
+// a negative number if a < b, 0 if a == b and a positive number if a > b.
+func compareValues(a, b any) int {
+	ta, tb := typeBracket(a), typeBracket(b)
+	if ta != tb {
+		return ta - tb
+	}
+
+	switch ta {
+	case 2:
+		return compareNumbers(a, b)
+	case 3:
+		return strings.Compare(toString(a), toString(b))
+	case 4:
+		da, _ := toDocument(a)
+		db, _ := toDocument(b)
+		for i := 0; i < len(da) && i < len(db); i++ {
+			if c := strings.Compare(da[i].Key, db[i].Key); c != 0 {
+				return c
+			}
+			if c := compareValues(da[i].Value, db[i].Value); c != 0 {
+				return c
+			}
+		}
+		return len(da) - len(db)
+	case 5:
+		aa, _ := toSlice(a)
+		ab, _ := toSlice(b)
+		for i := 0; i < len(aa) && i < len(ab); i++ {
+			if c := compareValues(aa[i], ab[i]); c != 0 {
+				return c
+			}
+		}
+		return len(aa) - len(ab)
+	case 6:
+		ba, bb := a.(primitive.Binary), b.(primitive.Binary)
+		if len(ba.Data) != len(bb.Data) {
+			return len(ba.Data) - len(bb.Data)
+		}
+		if ba.Subtype != bb.Subtype {
+			return int(ba.Subtype) - int(bb.Subtype)
+		}
+		return bytes.Compare(ba.Data, bb.Data)
+	case 7:
+		oa, ob := a.(primitive.ObjectID), b.(primitive.ObjectID)
+		return bytes.Compare(oa[:], ob[:])
+	case 8:
+		ba, bb := a.(bool), b.(bool)
+		switch {
+		case ba == bb:
+			return 0
+		case bb:
+			return -1
+		}
+		return 1
+	case 9:
+		ma, mb := toMillis(a), toMillis(b)
+		switch {
+		case ma < mb:
+			return -1
+		case ma > mb:
+			return 1
+		}
+		return 0
+	case 10:
+		return primitive.CompareTimestamp(a.(primitive.Timestamp), b.(primitive.Timestamp))
+	case 11:
+		ra, rb := a.(primitive.Regex), b.(primitive.Regex)
+		if c := strings.Compare(ra.Pattern, rb.Pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(ra.Options, rb.Options)
+	}
+	return 0
+}
+
+// compareNumbers compares two numbers of any BSON number type. Integral types
+// are compared exactly, all other numbers as rational numbers. NaN is only equal
+// to NaN and less than all other numbers.
+func compareNumbers(a, b any) int {
+	if ia, ok := integral(a); ok {
+		if ib, ok := integral(b); ok {
+			switch {
+			case ia < ib:
+				return -1
+			case ia > ib:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	ka, kb := numberKind(a), numberKind(b)
+	if ka != kb || ka != 0 {
+		// NaN (-2) < -Infinity (-1) < finite numbers (0) < Infinity (1)
+		switch {
+		case ka == kb:
+			return 0
+		case ka == -2 || kb == -2:
+			if ka == -2 {
+				return -1
+			}
+			return 1
+		case ka < kb:
+			return -1
+		}
+		return 1
+	}
+	ra, _ := toRat(a)
+	rb, _ := toRat(b)
+	return ra.Cmp(rb)
+}
+
+func integral(value any) (int64, bool) {
+	switch value.(type) {
+	case float32, float64, primitive.Decimal128:
+		return 0, false
+	}
+	return toInt64(value)
+}
+
+// numberKind returns -2 for NaN, -1 for -Infinity, 1 for Infinity and 0 for all
+// finite numbers.
+func numberKind(value any) int {
+	switch v := value.(type) {
+	case float32:
+		return numberKind(float64(v))
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return -2
+		case math.IsInf(v, 0):
+			if v < 0 {
+				return -1
+			}
+			return 1
+		}
+	case primitive.Decimal128:
+		switch {
+		case v.IsNaN():
+			return -2
+		case v.IsInf() != 0:
+			return v.IsInf()
+		}
+	}
+	return 0
+}
+
+// toRat converts a finite number to a rational number without loss of precision.
+func toRat(value any) (*big.Rat, bool) {
+	if numberKind(value) != 0 {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case float32:
+		return new(big.Rat).SetFloat64(float64(v)), true
+	case float64:
+		return new(big.Rat).SetFloat64(v), true
+	case primitive.Decimal128:
+		return new(big.Rat).SetString(v.String())
+	}
+	if i, ok := integral(value); ok {
+		return new(big.Rat).SetInt64(i), true
+	}
+	return nil, false
+}
+
+func toFloat(value any) float64 {
+	switch value.(type) {
+	case int:
+		return float64(value.(int))
+	case int8:
+		return float64(value.(int8))
+	case int16:
+		return float64(value.(int16))
+	case int32:
+		return float64(value.(int32))
+	case int64:
+		return float64(value.(int64))
+	case uint8:
+		return float64(value.(uint8))
+	case uint16:
+		return float64(value.(uint16))
+	case uint32:
+		return float64(value.(uint32))
+	case float32:
+		return float64(value.(float32))
+	case float64:
+		return value.(float64)
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(value.(primitive.Decimal128).String(), 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+	}
+	return math.NaN()
+}
+
+func toString(value any) string {
+	if symbol, ok := value.(primitive.Symbol); ok {
+		return string(symbol)
+	}
+	return value.(string)
+}
+
+func toMillis(value any) int64 {
+	if t, ok := value.(time.Time); ok {
+		return t.UnixMilli()
+	}
+	return int64(value.(primitive.DateTime))
+}
+
+func matchRegex(s, pattern, options string) (bool, error) {
+	re, err := compileRegex(pattern, options)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+func compileRegex(pattern, options string) (*regexp.Regexp, error) {
+	flags := ""
+	for _, option := range options {
+		switch option {
+		case 'i', 'm', 's':
+			flags += string(option)
+		case 'M':
+			flags += "m"
+		case 'x':
+			pattern = stripExtendedPattern(pattern)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// stripExtendedPattern removes unescaped white space and comments from a pattern
+// (like the "x" option of PCRE does).
+func stripExtendedPattern(pattern string) string {
+	var sb strings.Builder
+	escaped, inClass, inComment := false, false, false
+	for _, r := range pattern {
+		switch {
+		case inComment:
+			inComment = r != '\n'
+			continue
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '[':
+			inClass = true
+		case r == ']':
+			inClass = false
+		case !inClass && r == '#':
+			inComment = true
+			continue
+		case !inClass && (r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'):
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
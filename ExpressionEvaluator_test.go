@@ -0,0 +1,210 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var evaluatorTestDocument = bson.D{
+	{"name", "Horto flat with small garden"},
+	{"bedrooms", int32(1)},
+	{"price", mustDecimal("317.00")},
+	{"amenities", bson.A{"Wifi", "Kitchen", "Iron"}},
+	{"cleaning_fee", nil},
+	{"address", bson.D{{"country", "Brazil"}}},
+	{"reviews", bson.A{
+		bson.D{{"reviewer_name", "Milo"}, {"rating", int64(9)}},
+		bson.D{{"reviewer_name", "Anna"}},
+	}},
+	{"results", bson.A{int32(82), int32(85), int32(88)}},
+	{"nan", math.NaN()},
+	{"long", int64(9007199254740992)},
+	{"negative", int64(-1)},
+	{"zero", int32(0)},
+}
+
+var evaluatorTestData = []struct {
+	testName string
+	filter   Expression
+	expected bool
+}{
+	{"equals", Listing.Name.Equals("Horto flat with small garden"), true},
+	{"equals other value", Listing.Name.Equals("Test"), false},
+	{"numeric types are compared by value", Listing.Bedrooms.Equals(1.0), true},
+	{"decimal compared with int", Listing.Price.Gt(300), true},
+	{"no cross type comparison", Listing.Name.Gt(1), false},
+	{"ne", Listing.Bedrooms.Ne(2), true},
+	{"NaN does not equal number", Field("nan").Equals(5), false},
+	{"NaN equals NaN", Field("nan").Equals(math.NaN()), true},
+	{"NaN equals decimal NaN", Field("nan").Equals(mustDecimal("NaN")), true},
+	{"NaN is less than every number", Field("nan").Lt(math.Inf(-1)), true},
+	{"number is not less than NaN", Listing.Bedrooms.Lt(math.NaN()), false},
+	{"int64 compared exactly", Field("long").Equals(int64(9007199254740993)), false},
+	{"int64 equals", Field("long").Equals(int64(9007199254740992)), true},
+	{"int64 ordered exactly", Field("long").Lt(int64(9007199254740993)), true},
+	{"int64 compared exactly with double", Field("long").Equals(9007199254740992.0), true},
+	{"decimal equals double", Listing.Price.Equals(317.0), true},
+	{"decimal equals int", Listing.Price.Equals(317), true},
+	{"decimal compared exactly with double", Listing.Price.Lt(317.00000000000006), true},
+	{"null matches null", Listing.CleaningFee.Equals(nil), true},
+	{"null matches missing", Field("weekly_price").Equals(nil), true},
+	{"null does not match value", Listing.Name.Equals(nil), false},
+	{"gte null matches missing", Field("weekly_price").Gte(nil), true},
+	{"lte null matches null", Listing.CleaningFee.Lte(nil), true},
+	{"gt null matches nothing", Field("weekly_price").Gt(nil), false},
+	{"gte null does not match value", Listing.Name.Gte(nil), false},
+	{"regex value matches pattern", Listing.Name.Equals(primitive.Regex{Pattern: "^Horto"}), true},
+	{"regex value in in matches pattern", Listing.Name.In(primitive.Regex{Pattern: "^Horto"}), true},
+	{"regex value of eq does not match pattern", Listing.Name.Where(Equals(primitive.Regex{Pattern: "^Horto"})), false},
+	{"regex value of ne does not match pattern", Listing.Name.Ne(primitive.Regex{Pattern: "^Horto"}), true},
+	{"exists for null value", Listing.CleaningFee.Exists(), true},
+	{"not exists", Field("weekly_price").NotExists(), true},
+	{"nested field", Listing.Address.Country.Equals("Brazil"), true},
+	{"array contains value", Listing.Amenities.ArrayContainsElement(Equals("Wifi")), true},
+	{"array traversal", Review.ReviewerName.Equals("Anna"), true},
+	{"array traversal with index", Review.ElementNo(1).ReviewerName.Equals("Milo"), false},
+	{"array traversal with missing field", Field("reviews.rating").Equals(nil), true},
+	{"exists in array element", Field("reviews.rating").Exists(), true},
+	{"in", Listing.Bedrooms.In(1, 2), true},
+	{"not in", Field("amenities").NotIn("Wifi"), false},
+	{"all", Listing.Amenities.ArrayContainsAll("Wifi", "Iron"), true},
+	{"all missing element", Listing.Amenities.ArrayContainsAll("Wifi", "Pool"), false},
+	{"exact array", Listing.Amenities.ArrayContainsExact("Wifi", "Kitchen", "Iron"), true},
+	{"exact array other order", Listing.Amenities.ArrayContainsExact("Kitchen", "Wifi", "Iron"), false},
+	{"size", Listing.Amenities.ArraySize(3), true},
+	{"size mismatch", Listing.Amenities.ArraySize(2), false},
+	{"regex", Review.ReviewerName.Regex("^mi", RegexpOptionCaseInsensitivity), true},
+	{"regex case sensitive", Review.ReviewerName.Regex("^mi"), false},
+	{"regex extended", Listing.Name.Regex("^Horto \\s flat # comment", RegexpOptionExtended), true},
+	{"not regex", Listing.Name.Not(Regex("^H")), false},
+	{"elemMatch", ArrayField("results").ArrayElemMatch(Gte(80), Lt(85)), true},
+	{"elemMatch no element", ArrayField("results").ArrayElemMatch(Gt(82), Lt(85)), false},
+	{"elemMatch expression", Listing.Reviews.ArrayElemMatchExpression(
+		Review.ReviewerName.Equals("Milo"), Field("reviews.rating").Gt(8)), true},
+	{"type", Listing.Bedrooms.Type(BsonTypeInt), true},
+	{"type number", Listing.Price.Type(BsonTypeNumber), true},
+	{"mod", Field("results").Mod(4, 0), true},
+	{"bits all set", Listing.Bedrooms.BitsAllSet(BitPositions(0)), true},
+	{"bit 63 of zero", Field("zero").BitsAllSet(BitPositions(63)), false},
+	{"bit 64 of zero", Field("zero").BitsAnySet(BitPositions(64)), false},
+	{"bit 70 of zero", Field("zero").BitsAllSet(BitPositions(70)), false},
+	{"bit 70 of zero is clear", Field("zero").BitsAllClear(BitPositions(70)), true},
+	{"high bits of negative number are set", Field("negative").BitsAllSet(BitPositions(63, 64, 70)), true},
+	{"bit 64 of positive number", Field("long").BitsAnySet(BitPositions(64)), false},
+	{"long binary mask all set", Field("negative").BitsAllSet(BinaryBitMask(primitive.Binary{Data: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x01}})), true},
+	{"long binary mask any set", Listing.Bedrooms.BitsAnySet(BinaryBitMask(primitive.Binary{Data: []byte{0x01, 0, 0, 0, 0, 0, 0, 0, 0x01}})), true},
+	{"long binary mask not all set", Listing.Bedrooms.BitsAllSet(BinaryBitMask(primitive.Binary{Data: []byte{0x01, 0, 0, 0, 0, 0, 0, 0, 0x01}})), false},
+	{"and", Listing.Bedrooms.Equals(1).And(Listing.Name.Equals("Test")), false},
+	{"or", Listing.Bedrooms.Equals(1).Or(Listing.Name.Equals("Test")), true},
+	{"nor", Listing.Bedrooms.Equals(2).Nor(Listing.Name.Equals("Test")), true},
+}
+
+func TestExpression_Matches(t *testing.T) {
+
+	for _, datum := range evaluatorTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			matched, err := datum.filter.Matches(evaluatorTestDocument)
+
+			//then
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matched != datum.expected {
+				t.Errorf("expected %v but got %v for %v", datum.expected, matched, datum.filter)
+			}
+
+		})
+	}
+
+}
+
+func TestExpression_Matches_documentTypes(t *testing.T) {
+
+	//given
+	listing := ListingAndReview{Name: "Horto flat with small garden", Bedrooms: 1}
+	raw, _ := bson.Marshal(evaluatorTestDocument)
+
+	for name, document := range map[string]any{
+		"struct": listing,
+		"bson.M": bson.M{"name": "Horto flat with small garden", "bedrooms": 1},
+		"bson.D": evaluatorTestDocument,
+		"raw":    bson.Raw(raw),
+	} {
+		t.Run(name, func(t *testing.T) {
+
+			//when
+			matched, err := Listing.Name.Equals("Horto flat with small garden").
+				And(Listing.Bedrooms.Lte(1)).Matches(document)
+
+			//then
+			if err != nil || !matched {
+				t.Errorf("expected match but got %v (%v)", matched, err)
+			}
+
+		})
+	}
+
+}
+
+func TestExpression_Matches_notSupported(t *testing.T) {
+
+	//when
+	_, err := Text("coffee").Matches(evaluatorTestDocument)
+
+	//then
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported but got %v", err)
+	}
+
+}
+
+func TestExpression_Matches_invalidBitMask(t *testing.T) {
+
+	//when
+	_, err := Listing.Bedrooms.BitsAllSet(BitPositions(-1)).Matches(evaluatorTestDocument)
+
+	//then
+	if !errors.Is(err, ErrInvalidOperand) {
+		t.Errorf("expected ErrInvalidOperand but got %v", err)
+	}
+
+}
+
+func mustDecimal(s string) primitive.Decimal128 {
+	d, err := primitive.ParseDecimal128(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
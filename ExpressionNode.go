@@ -0,0 +1,231 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NodeKind defines the kind of a Node.
+type NodeKind int
+
+const (
+	// NodeLogical is a logical operation ("$and", "$or", "$nor"). The children are
+	// the combined expressions.
+	NodeLogical NodeKind = iota
+	// NodeField is a condition for a single field. If the node has no children the
+	// field has to be equal to the value. Otherwise the children are either
+	// operators (NodeOperator) or - for exact matches of arrays of embedded
+	// documents - expressions.
+	NodeField
+	// NodeOperator is a query operator (e.g. "$gt") with its operand value. Operators
+	// which wrap other operators (e.g. "$not") or expressions (e.g. "$elemMatch")
+	// have no value but children.
+	NodeOperator
+	// NodeText is a text search. The value is the document of the "$text" operator.
+	NodeText
+	// NodeExpr is an aggregation expression. The value is the AggregationExpression.
+	NodeExpr
+	// NodeJSONSchema is a JSON schema. The value is the Schema.
+	NodeJSONSchema
+)
+
+// String returns the name of the NodeKind.
+func (k NodeKind) String() string {
+	switch k {
+	case NodeLogical:
+		return "Logical"
+	case NodeField:
+		return "Field"
+	case NodeOperator:
+		return "Operator"
+	case NodeText:
+		return "Text"
+	case NodeExpr:
+		return "Expr"
+	case NodeJSONSchema:
+		return "JSONSchema"
+	}
+	return "Unknown"
+}
+
+// Node is a read-only representation of an Expression (or a part of it) which
+// can be used to inspect or rewrite filters (see Walk and Rewrite).
+//
+// Field paths of expressions within "$elemMatch" are relative to the array element.
+type Node struct {
+	Kind     NodeKind
+	Field    Field
+	Operator string
+	Value    any
+	Children []Node
+	err      error
+}
+
+// Node returns the tree representation of the Expression.
+func (e Expression) Node() Node {
+	switch e.value.(type) {
+	case LogicalOperator:
+		lo := e.value.(LogicalOperator)
+		return Node{Kind: NodeLogical, Operator: lo.operator, Children: expressionNodes(lo.expressions), err: e.err}
+	case textSearch:
+		ts := e.value.(textSearch)
+		return Node{Kind: NodeText, Operator: "$text", Value: ts.bson()[0].Value, err: e.err}
+	case AggregationExpression:
+		return Node{Kind: NodeExpr, Operator: "$expr", Value: e.value, err: e.err}
+	case Schema:
+		return Node{Kind: NodeJSONSchema, Operator: "$jsonSchema", Value: e.value, err: e.err}
+	case QueryOperator:
+		qo := e.value.(QueryOperator)
+		return Node{Kind: NodeField, Field: e.field, Children: []Node{qo.node()}, err: e.err}
+	case []QueryOperator:
+		return Node{Kind: NodeField, Field: e.field, Children: queryOperatorNodes(e.value.([]QueryOperator)), err: e.err}
+	case []Expression:
+		return Node{Kind: NodeField, Field: e.field, Children: expressionNodes(e.value.([]Expression)), err: e.err}
+	}
+	return Node{Kind: NodeField, Field: e.field, Value: e.value, err: e.err}
+}
+
+func (qo QueryOperator) node() Node {
+	switch qo.value.(type) {
+	case []QueryOperator:
+		return Node{Kind: NodeOperator, Operator: qo.operator, Children: queryOperatorNodes(qo.value.([]QueryOperator))}
+	case []Expression:
+		return Node{Kind: NodeOperator, Operator: qo.operator, Children: expressionNodes(qo.value.([]Expression))}
+	}
+	return Node{Kind: NodeOperator, Operator: qo.operator, Value: qo.value}
+}
+
+func expressionNodes(expressions []Expression) []Node {
+	nodes := make([]Node, 0, len(expressions))
+	for _, expression := range expressions {
+		nodes = append(nodes, expression.Node())
+	}
+	return nodes
+}
+
+func queryOperatorNodes(operators []QueryOperator) []Node {
+	nodes := make([]Node, 0, len(operators))
+	for _, operator := range operators {
+		nodes = append(nodes, operator.node())
+	}
+	return nodes
+}
+
+// Expression builds the Expression represented by the node. It must not be
+// called for nodes of kind NodeOperator - use QueryOperator instead.
+func (n Node) Expression() Expression {
+	switch n.Kind {
+	case NodeLogical:
+		return Expression{value: LogicalOperator{operator: n.Operator, expressions: n.childExpressions()}, err: n.err}
+	case NodeText:
+		return Expression{value: textSearchFromBSON(n.Value), err: n.err}
+	case NodeExpr, NodeJSONSchema:
+		return Expression{value: n.Value, err: n.err}
+	case NodeOperator:
+		return Expression{value: n.QueryOperator(), err: n.err}
+	}
+
+	switch {
+	case len(n.Children) == 0:
+		return Expression{field: n.Field, value: n.Value, err: n.err}
+	case n.Children[0].Kind != NodeOperator:
+		return Expression{field: n.Field, value: n.childExpressions(), err: n.err}
+	case len(n.Children) == 1:
+		return Expression{field: n.Field, value: n.Children[0].QueryOperator(), err: n.err}
+	}
+	return Expression{field: n.Field, value: n.childQueryOperators(), err: n.err}
+}
+
+// QueryOperator builds the QueryOperator represented by a node of kind
+// NodeOperator.
+func (n Node) QueryOperator() QueryOperator {
+	switch {
+	case len(n.Children) == 0:
+		return QueryOperator{operator: n.Operator, value: n.Value}
+	case n.Children[0].Kind == NodeOperator:
+		return QueryOperator{operator: n.Operator, value: n.childQueryOperators()}
+	}
+	return QueryOperator{operator: n.Operator, value: n.childExpressions()}
+}
+
+func (n Node) childExpressions() []Expression {
+	expressions := make([]Expression, 0, len(n.Children))
+	for _, child := range n.Children {
+		expressions = append(expressions, child.Expression())
+	}
+	return expressions
+}
+
+func (n Node) childQueryOperators() []QueryOperator {
+	operators := make([]QueryOperator, 0, len(n.Children))
+	for _, child := range n.Children {
+		operators = append(operators, child.QueryOperator())
+	}
+	return operators
+}
+
+func textSearchFromBSON(value any) textSearch {
+	ts := textSearch{}
+	d, _ := value.(bson.D)
+	for _, e := range d {
+		if e.Key == "$search" {
+			ts.search, _ = e.Value.(string)
+			continue
+		}
+		ts.opts = append(ts.opts, TextOption{key: e.Key, value: e.Value})
+	}
+	return ts
+}
+
+// Walk traverses the node and all its children depth-first and calls fn for
+// every node. If fn returns false, the children of the node are skipped.
+func Walk(node Node, fn func(Node) bool) {
+	if !fn(node) {
+		return
+	}
+	for _, child := range node.Children {
+		Walk(child, fn)
+	}
+}
+
+// Rewrite builds a new Expression by calling fn for every node of the given
+// Expression. The children of a node are rewritten before the node itself, so fn
+// always gets a node with already rewritten children. The given Expression is
+// not modified.
+func Rewrite(e Expression, fn func(Node) Node) Expression {
+	return rewriteNode(e.Node(), fn).Expression()
+}
+
+func rewriteNode(node Node, fn func(Node) Node) Node {
+	if len(node.Children) > 0 {
+		children := make([]Node, 0, len(node.Children))
+		for _, child := range node.Children {
+			children = append(children, rewriteNode(child, fn))
+		}
+		node.Children = children
+	}
+	return fn(node)
+}
@@ -0,0 +1,198 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var nodeTestData = []Expression{
+	Listing.Name.Equals("x"),
+	Listing.Bedrooms.Gt(2).And(Listing.Name.Equals("A").Or(Listing.Name.Regex("^B", RegexpOptionCaseInsensitivity))),
+	Listing.Bedrooms.Gt(2).Nor(Listing.Bedrooms.Not(Gte(2), Lte(9))),
+	Listing.Amenities.ArrayElemMatch(Regex("^Wi"), Ne("Wifi")),
+	Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("Milo").Or(Review.ReviewerName.Equals("Mike")), Review.Comments.Exists()),
+	Listing.Reviews.ArrayContainsElementMatchesExpression(Review.ReviewerName.Equals("Milo")),
+	Listing.Bathrooms.NotType(BsonTypeDecimal, BsonTypeNull),
+	Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-8.7, 41.1), UpperRight: Pos(-8.5, 41.2)}),
+	Listing.Bedrooms.BitsAllSet(BitPositions(0, 2)),
+	Listing.Bedrooms.Between(2, 4, IncludeBoth).And(Text("beach", TextLanguage("en"))),
+	Expr(Listing.Price.Ref().Lt(Listing.CleaningFee)),
+	JSONSchema(Schema{Required: []string{"name"}}),
+}
+
+func TestRewrite_identity(t *testing.T) {
+
+	for _, expression := range nodeTestData {
+
+		//when
+		rewritten := Rewrite(expression, func(node Node) Node { return node })
+
+		//then
+		if !reflect.DeepEqual(rewritten.bsonD(), expression.bsonD()) {
+			t.Errorf("rewritten expression differs: %v, original: %v", rewritten.bsonD(), expression.bsonD())
+		}
+	}
+
+}
+
+func TestExpression_Node(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Gt(2).Or(Listing.Name.Equals("A"), Listing.Name.Not(Regex("^B")))
+
+	//when
+	node := f1.Node()
+
+	//then
+	expected := Node{Kind: NodeLogical, Operator: "$or", Children: []Node{
+		{Kind: NodeField, Field: "bedrooms", Children: []Node{{Kind: NodeOperator, Operator: "$gt", Value: 2}}},
+		{Kind: NodeField, Field: "name", Value: "A"},
+		{Kind: NodeField, Field: "name", Children: []Node{
+			{Kind: NodeOperator, Operator: "$not", Children: []Node{{Kind: NodeOperator, Operator: "$regex", Value: "^B"}}},
+		}},
+	}}
+	if !reflect.DeepEqual(node, expected) {
+		t.Errorf("expected node %+v but got %+v", expected, node)
+	}
+
+}
+
+func TestWalk_collectFields(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Gt(2).
+		And(Listing.Name.Equals("A").Or(Listing.Bedrooms.Lt(1)),
+			Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("Milo")))
+
+	//when
+	var fields []Field
+	Walk(f1.Node(), func(node Node) bool {
+		if node.Kind == NodeField {
+			fields = append(fields, node.Field)
+		}
+		return true
+	})
+
+	//then
+	expected := []Field{"bedrooms", "name", "bedrooms", "reviews", "reviewer_name"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected fields %v but got %v", expected, fields)
+	}
+
+}
+
+func TestWalk_skipChildren(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Gt(2).And(Listing.Name.Equals("A").Or(Listing.Bedrooms.Lt(1)))
+
+	//when
+	count := 0
+	Walk(f1.Node(), func(node Node) bool {
+		count++
+		return node.Operator != "$or"
+	})
+
+	//then
+	// $and, bedrooms, $gt, $or
+	if count != 4 {
+		t.Errorf("expected 4 visited nodes but got %d", count)
+	}
+
+}
+
+func TestRewrite_redactValues(t *testing.T) {
+
+	//given
+	f1 := Listing.Name.Equals("secret").Or(Listing.Bedrooms.Where(Gt(2), Lt(5)), Listing.Name.Not(Regex("^s")))
+
+	//when
+	redacted := Rewrite(f1, func(node Node) Node {
+		if node.Value != nil {
+			node.Value = "***"
+		}
+		return node
+	})
+
+	//then
+	expected := bson.D{{"$or", []bson.D{
+		{{"name", "***"}},
+		{{"bedrooms", bson.D{{"$gt", "***"}, {"$lt", "***"}}}},
+		{{"name", bson.D{{"$not", bson.D{{"$regex", "***"}}}}}},
+	}}}
+	if !reflect.DeepEqual(redacted.bsonD(), expected) {
+		t.Errorf("expected %v but got %v", expected, redacted.bsonD())
+	}
+	if !reflect.DeepEqual(f1.Node().Children[0].Value, "secret") {
+		t.Errorf("original expression has been modified")
+	}
+
+}
+
+func TestRewrite_fieldPrefix(t *testing.T) {
+
+	//given
+	f1 := Listing.Images.PictureUrl.Exists().And(Listing.Images.MediumUrl.Equals("x"), Listing.Name.Equals("y"))
+
+	//when
+	rewritten := Rewrite(f1, func(node Node) Node {
+		if node.Kind == NodeField && strings.HasPrefix(string(node.Field), "images.") {
+			node.Field = Field("photos." + strings.TrimPrefix(string(node.Field), "images."))
+		}
+		return node
+	})
+
+	//then
+	expected := bson.D{{"$and", []bson.D{
+		{{"photos.picture_url", bson.D{{"$exists", true}}}},
+		{{"photos.medium_url", "x"}},
+		{{"name", "y"}},
+	}}}
+	if !reflect.DeepEqual(rewritten.bsonD(), expected) {
+		t.Errorf("expected %v but got %v", expected, rewritten.bsonD())
+	}
+
+}
+
+func TestRewrite_keepsBuildErrors(t *testing.T) {
+
+	//given
+	f1 := Listing.Name.Exists().And(Listing.Bedrooms.Where(Gt(1), Gt(2)))
+
+	//when
+	rewritten := Rewrite(f1, func(node Node) Node { return node })
+	_, err := bson.Marshal(rewritten)
+
+	//then
+	if !errors.Is(err, ErrDuplicateOperator) {
+		t.Errorf("expected error %v but got: %v", ErrDuplicateOperator, err)
+	}
+
+}
@@ -0,0 +1,404 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrContradiction is returned by Normalize if an expression can never match a
+// document, e.g. x == 1 AND x == 2.
+var ErrContradiction = errors.New("contradicting conditions")
+
+// Normalize returns a simplified but equivalent Expression:
+//
+//   - nested "$and" and "$or" operators are flattened
+//   - duplicate clauses are dropped
+//   - conditions on the same field within "$and" are merged into one operator
+//     document and redundant range bounds are removed
+//   - "$in" and "$nin" with a single value are replaced by "$eq" and "$ne"
+//
+// If the Expression or one of the branches of "$or" can never match, the
+// normalized Expression is returned together with an error wrapping
+// ErrContradiction. Contradictions are detected under the assumption that the
+// fields do not contain arrays (for arrays x == 1 AND x == 2 can match different
+// elements), so contradicting clauses are reported but never dropped.
+func (e Expression) Normalize() (Expression, error) {
+	if err := e.buildError(); err != nil {
+		return e, err
+	}
+	return e.normalize()
+}
+
+func (e Expression) normalize() (Expression, error) {
+	lo, ok := e.value.(LogicalOperator)
+	if !ok {
+		if e.field == "" {
+			return e, nil
+		}
+		ops, ok := e.fieldOperators()
+		if !ok {
+			return e, nil
+		}
+		merged := fieldConditions{field: e.field}
+		for _, op := range ops {
+			if !merged.add(op) {
+				return e, nil
+			}
+		}
+		err := merged.check()
+		return merged.expression(), err
+	}
+
+	var children []Expression
+	var contradiction error
+	for _, child := range lo.expressions {
+		normalized, err := child.normalize()
+		if err != nil {
+			switch lo.operator {
+			case "$and":
+				contradiction = err
+			case "$or":
+				// branch can never match for scalar values, but may match arrays
+				if contradiction == nil {
+					contradiction = err
+				}
+			case "$nor":
+				// keep the original clause, a contradiction never matches anyway
+				normalized = child
+			}
+		}
+		if nested, ok := normalized.value.(LogicalOperator); ok && nested.operator == lo.operator && lo.operator != "$nor" {
+			children = append(children, nested.expressions...)
+			continue
+		}
+		children = append(children, normalized)
+	}
+
+	children = distinctExpressions(children)
+	if lo.operator == "$and" {
+		var err error
+		children, err = mergeFieldConditions(children)
+		if err != nil {
+			contradiction = err
+		}
+	}
+
+	if len(children) == 1 && lo.operator != "$nor" {
+		return children[0], contradiction
+	}
+	return Expression{value: LogicalOperator{operator: lo.operator, expressions: children}}, contradiction
+}
+
+// fieldOperators returns the operators of a field expression. The second return
+// value is false, if the expression can not be merged with other expressions.
+func (e Expression) fieldOperators() ([]QueryOperator, bool) {
+	var ops []QueryOperator
+	switch e.value.(type) {
+	case QueryOperator:
+		ops = []QueryOperator{e.value.(QueryOperator)}
+	case []QueryOperator:
+		ops = append(ops, e.value.([]QueryOperator)...)
+	case []Expression, textSearch, AggregationExpression, Schema, LogicalOperator:
+		return nil, false
+	case primitive.Regex, bson.D, bson.M, map[string]any:
+		return nil, false
+	default:
+		ops = []QueryOperator{Equals(e.value)}
+	}
+
+	for i, op := range ops {
+		values, ok := op.value.([]any)
+		if !ok || len(values) != 1 {
+			continue
+		}
+		if _, isRegex := values[0].(primitive.Regex); isRegex {
+			continue
+		}
+		switch op.operator {
+		case "$in":
+			ops[i] = Equals(values[0])
+		case "$nin":
+			ops[i] = Ne(values[0])
+		}
+	}
+	return ops, true
+}
+
+func distinctExpressions(expressions []Expression) []Expression {
+	var distinct []Expression
+	seen := make(map[string]bool)
+	for _, expression := range expressions {
+		key := expression.Render(StyleCanonicalExtJSON)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, expression)
+	}
+	return distinct
+}
+
+// mergeFieldConditions merges all expressions of the same field (combined with
+// "$and") into a single expression at the position of the first expression.
+func mergeFieldConditions(expressions []Expression) ([]Expression, error) {
+	var merged []Expression
+	var conditions []*fieldConditions
+	byField := make(map[Field]*fieldConditions)
+	var contradiction error
+
+	for _, expression := range expressions {
+		ops, ok := expression.fieldOperators()
+		if !ok || expression.field == "" {
+			merged = append(merged, expression)
+			conditions = append(conditions, nil)
+			continue
+		}
+		if fc, exists := byField[expression.field]; exists {
+			candidate := fc.copy()
+			if candidate.addAll(ops) {
+				*fc = candidate
+				continue
+			}
+			// the conditions can not be combined in one operator document
+			if fc.conflicts(ops) {
+				contradiction = fmt.Errorf("%w: %s and %s", ErrContradiction, fc.expression(), expression)
+			}
+			merged = append(merged, expression)
+			conditions = append(conditions, nil)
+			continue
+		}
+		fc := &fieldConditions{field: expression.field}
+		if !fc.addAll(ops) {
+			merged = append(merged, expression)
+			conditions = append(conditions, nil)
+			continue
+		}
+		byField[expression.field] = fc
+		merged = append(merged, expression)
+		conditions = append(conditions, fc)
+	}
+
+	for i, fc := range conditions {
+		if fc == nil {
+			continue
+		}
+		if err := fc.check(); err != nil && contradiction == nil {
+			contradiction = err
+		}
+		merged[i] = fc.expression()
+	}
+	return merged, contradiction
+}
+
+// fieldConditions collects the operators of a single field. Each operator is
+// used once.
+type fieldConditions struct {
+	field Field
+	ops   []QueryOperator
+}
+
+func (fc *fieldConditions) copy() fieldConditions {
+	return fieldConditions{field: fc.field, ops: append([]QueryOperator(nil), fc.ops...)}
+}
+
+func (fc *fieldConditions) addAll(ops []QueryOperator) bool {
+	for _, op := range ops {
+		if !fc.add(op) {
+			return false
+		}
+	}
+	return true
+}
+
+// add adds the operator. It returns false, if the operator can not be combined
+// with the existing operators.
+func (fc *fieldConditions) add(op QueryOperator) bool {
+	if bound := boundKind(op.operator); bound != 0 {
+		for i, existing := range fc.ops {
+			if boundKind(existing.operator) != bound {
+				continue
+			}
+			if !orderable(existing.value, op.value) {
+				return false
+			}
+			c := compareValues(op.value, existing.value) * bound
+			if c > 0 || (c == 0 && isExclusive(op.operator)) {
+				fc.ops[i] = op
+			}
+			return true
+		}
+	}
+
+	for _, existing := range fc.ops {
+		if existing.operator == op.operator {
+			return sameValue(existing.value, op.value)
+		}
+	}
+	fc.ops = append(fc.ops, op)
+	return true
+}
+
+// conflicts reports if the operators can not be satisfied together with the
+// existing operators.
+func (fc *fieldConditions) conflicts(ops []QueryOperator) bool {
+	for _, op := range ops {
+		for _, existing := range fc.ops {
+			if existing.operator != op.operator {
+				continue
+			}
+			switch op.operator {
+			case "$eq", "$exists":
+				return differentValues(existing.value, op.value)
+			}
+		}
+	}
+	return false
+}
+
+// check returns an error wrapping ErrContradiction if the conditions can never
+// be satisfied. Redundant bounds are removed.
+func (fc *fieldConditions) check() error {
+	eq, hasEq := fc.op("$eq")
+	lower, hasLower := fc.bound(1)
+	upper, hasUpper := fc.bound(-1)
+
+	contradiction := func() error {
+		return fmt.Errorf("%w: %s", ErrContradiction, fc.expression())
+	}
+
+	if ne, ok := fc.op("$ne"); ok && hasEq && sameValue(ne.value, eq.value) {
+		return contradiction()
+	}
+	if exists, ok := fc.op("$exists"); ok && exists.value == false && hasEq && eq.value != nil {
+		return contradiction()
+	}
+	if hasLower && hasUpper && orderable(lower.value, upper.value) {
+		c := compareValues(lower.value, upper.value)
+		if c > 0 || (c == 0 && (isExclusive(lower.operator) || isExclusive(upper.operator))) {
+			return contradiction()
+		}
+	}
+	if !hasEq {
+		return nil
+	}
+	for _, bound := range []QueryOperator{lower, upper} {
+		if bound.operator == "" || !orderable(eq.value, bound.value) {
+			continue
+		}
+		c := compareValues(eq.value, bound.value) * boundKind(bound.operator)
+		if c < 0 || (c == 0 && isExclusive(bound.operator)) {
+			return contradiction()
+		}
+		fc.remove(bound.operator)
+	}
+	return nil
+}
+
+func (fc *fieldConditions) op(operator string) (QueryOperator, bool) {
+	for _, op := range fc.ops {
+		if op.operator == operator {
+			return op, true
+		}
+	}
+	return QueryOperator{}, false
+}
+
+func (fc *fieldConditions) bound(kind int) (QueryOperator, bool) {
+	for _, op := range fc.ops {
+		if boundKind(op.operator) == kind {
+			return op, true
+		}
+	}
+	return QueryOperator{}, false
+}
+
+func (fc *fieldConditions) remove(operator string) {
+	var ops []QueryOperator
+	for _, op := range fc.ops {
+		if op.operator != operator {
+			ops = append(ops, op)
+		}
+	}
+	fc.ops = ops
+}
+
+func (fc *fieldConditions) expression() Expression {
+	if len(fc.ops) == 1 {
+		if fc.ops[0].operator == "$eq" {
+			return Expression{field: fc.field, value: fc.ops[0].value}
+		}
+		return Expression{field: fc.field, value: fc.ops[0]}
+	}
+	return Expression{field: fc.field, value: fc.ops}
+}
+
+// boundKind returns 1 for lower bounds, -1 for upper bounds and 0 otherwise.
+func boundKind(operator string) int {
+	switch operator {
+	case "$gt", "$gte":
+		return 1
+	case "$lt", "$lte":
+		return -1
+	}
+	return 0
+}
+
+func isExclusive(operator string) bool {
+	return operator == "$gt" || operator == "$lt"
+}
+
+// orderable reports if both values are of a known type with the same position in
+// the BSON comparison order.
+func orderable(a, b any) bool {
+	bracket := typeBracket(a)
+	return bracket == typeBracket(b) && bracket != 12 && bracket != 4 && bracket != 5
+}
+
+// sameValue reports if both values are equal. Values which can not be ordered
+// (e.g. the operators of "$not" or "$elemMatch") are compared deeply, so values
+// which can not be compared are treated as different.
+func sameValue(a, b any) bool {
+	if orderable(a, b) {
+		return compareValues(a, b) == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// differentValues reports if both values are scalar values which are known to
+// be different.
+func differentValues(a, b any) bool {
+	for _, bracket := range []int{typeBracket(a), typeBracket(b)} {
+		if bracket == 12 || bracket == 4 || bracket == 5 {
+			return false
+		}
+	}
+	return compareValues(a, b) != 0
+}
@@ -0,0 +1,209 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleExpression_Normalize() {
+
+	f1 := Listing.Bedrooms.Gt(1).
+		And(Listing.Name.Equals("Test").And(Listing.Bedrooms.Lt(5)), Listing.Bedrooms.Gt(2), Listing.Name.Equals("Test"))
+
+	normalized, _ := f1.Normalize()
+
+	fmt.Println(normalized)
+	// Output: bson.D{{"$and", []bson.D{bson.D{{"bedrooms", bson.D{{"$gt", 2},{"$lt", 5}}}}, bson.D{{"name", "Test"}}}}}
+
+}
+
+var normalizeTestData = []struct {
+	testName string
+	filter   Expression
+	expected Expression
+}{
+	{
+		"flattens nested and",
+		Listing.Name.Equals("a").And(Listing.Bedrooms.Equals(1).And(Listing.Price.Equals(2))),
+		Listing.Name.Equals("a").And(Listing.Bedrooms.Equals(1), Listing.Price.Equals(2)),
+	},
+	{
+		"flattens nested or",
+		Listing.Name.Equals("a").Or(Listing.Name.Equals("b").Or(Listing.Name.Equals("c"))),
+		Listing.Name.Equals("a").Or(Listing.Name.Equals("b"), Listing.Name.Equals("c")),
+	},
+	{
+		"keeps nested or in and",
+		Listing.Name.Equals("a").And(Listing.Bedrooms.Equals(1).Or(Listing.Bedrooms.Equals(2))),
+		Listing.Name.Equals("a").And(Listing.Bedrooms.Equals(1).Or(Listing.Bedrooms.Equals(2))),
+	},
+	{
+		"drops duplicates and unwraps single clause",
+		Listing.Name.Equals("a").Or(Listing.Name.Equals("a")),
+		Listing.Name.Equals("a"),
+	},
+	{
+		"merges conditions on the same field",
+		Listing.Bedrooms.Gte(1).And(Listing.Bedrooms.Lt(5), Listing.Bedrooms.Ne(3)),
+		Listing.Bedrooms.Where(Gte(1), Lt(5), Ne(3)),
+	},
+	{
+		"keeps the tighter bound",
+		Listing.Bedrooms.Gte(3).And(Listing.Bedrooms.Gt(3), Listing.Bedrooms.Lte(10), Listing.Bedrooms.Lt(8)),
+		Listing.Bedrooms.Where(Gt(3), Lt(8)),
+	},
+	{
+		"equality makes bounds redundant",
+		Listing.Bedrooms.Equals(4).And(Listing.Bedrooms.Gt(3)),
+		Listing.Bedrooms.Equals(4),
+	},
+	{
+		"single value in",
+		Listing.Bedrooms.In(3),
+		Listing.Bedrooms.Equals(3),
+	},
+	{
+		"single value not in",
+		Listing.Bedrooms.NotIn(3),
+		Listing.Bedrooms.Ne(3),
+	},
+	{
+		"different not operators are kept separate",
+		Listing.Bedrooms.Not(Gt(1)).And(Listing.Bedrooms.Not(Lt(5))),
+		Listing.Bedrooms.Not(Gt(1)).And(Listing.Bedrooms.Not(Lt(5))),
+	},
+	{
+		"different elemMatch operators are kept separate",
+		Listing.Amenities.ArrayElemMatch(Equals("a")).And(Listing.Amenities.ArrayElemMatch(Equals("b"))),
+		Listing.Amenities.ArrayElemMatch(Equals("a")).And(Listing.Amenities.ArrayElemMatch(Equals("b"))),
+	},
+	{
+		"different elemMatch expressions are kept separate",
+		Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("a")).
+			And(Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("b"))),
+		Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("a")).
+			And(Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("b"))),
+	},
+	{
+		"same not operators are merged",
+		Listing.Bedrooms.Not(Gt(1)).And(Listing.Bedrooms.Lt(5), Listing.Bedrooms.Not(Gt(1))),
+		Listing.Bedrooms.Where(Not(Gt(1)), Lt(5)),
+	},
+	{
+		"different operators are kept separate",
+		Listing.Name.Regex("^a").And(Listing.Name.Regex("b$")),
+		Listing.Name.Regex("^a").And(Listing.Name.Regex("b$")),
+	},
+}
+
+func TestExpression_Normalize(t *testing.T) {
+
+	for _, datum := range normalizeTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			normalized, err := datum.filter.Normalize()
+
+			//then
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if normalized.String() != datum.expected.String() {
+				t.Errorf("expected %v but got %v", datum.expected, normalized)
+			}
+
+		})
+	}
+
+}
+
+var contradictionTestData = []struct {
+	testName      string
+	filter        Expression
+	contradiction bool
+}{
+	{"different equality values", Listing.Bedrooms.Equals(1).And(Listing.Bedrooms.Equals(2)), true},
+	{"equality and not equals", Listing.Bedrooms.Equals(1).And(Listing.Bedrooms.Ne(1)), true},
+	{"empty range", Listing.Bedrooms.Gt(5).And(Listing.Bedrooms.Lt(3)), true},
+	{"empty range with exclusive bound", Listing.Bedrooms.Between(3, 3, IncludeLower), true},
+	{"equality outside of range", Listing.Bedrooms.Equals(1).And(Listing.Bedrooms.Gte(2)), true},
+	{"exists and not exists", Listing.Bedrooms.Exists().And(Listing.Bedrooms.NotExists()), true},
+	{"nested contradiction", Listing.Name.Equals("a").And(Listing.Bedrooms.In(1).And(Listing.Bedrooms.Equals(2))), true},
+	{"all branches of or", Listing.Bedrooms.Equals(1).And(Listing.Bedrooms.Equals(2)).
+		Or(Listing.Price.Gt(2).And(Listing.Price.Lt(1))), true},
+	{"single range", Listing.Bedrooms.Between(3, 3, IncludeBoth), false},
+	{"one branch of or", Listing.Bedrooms.Equals(1).And(Listing.Bedrooms.Equals(2)).Or(Listing.Name.Equals("a")), true},
+	{"different not operators", Listing.Bedrooms.Not(Gt(1)).And(Listing.Bedrooms.Not(Lt(5))), false},
+	{"different elemMatch operators", Listing.Amenities.ArrayElemMatch(Equals("a")).And(Listing.Amenities.ArrayElemMatch(Equals("b"))), false},
+	{"different types", Listing.Bedrooms.Gt(5).And(Listing.Bedrooms.Lt("3")), false},
+}
+
+func TestExpression_Normalize_contradiction(t *testing.T) {
+
+	for _, datum := range contradictionTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := datum.filter.Normalize()
+
+			//then
+			if errors.Is(err, ErrContradiction) != datum.contradiction {
+				t.Errorf("expected contradiction %v but got %v", datum.contradiction, err)
+			}
+
+		})
+	}
+
+}
+
+func TestExpression_Normalize_or_keepsContradictingBranch(t *testing.T) {
+
+	//given
+	f1 := Field(Listing.Amenities).Equals("a").And(Field(Listing.Amenities).Equals("b")).
+		Or(Listing.Name.Equals("x"))
+
+	//when
+	normalized, err := f1.Normalize()
+
+	//then
+	if !errors.Is(err, ErrContradiction) {
+		t.Errorf("expected ErrContradiction but got %v", err)
+	}
+	if normalized.String() != f1.String() {
+		t.Errorf("expected %v but got %v", f1, normalized)
+	}
+	matched, err := normalized.Matches(bson.D{{"amenities", bson.A{"a", "b"}}})
+	if err != nil || !matched {
+		t.Errorf("expected match but got %v (%v)", matched, err)
+	}
+
+}
@@ -0,0 +1,386 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownOperator is returned if a filter contains an operator which is not
+// supported.
+var ErrUnknownOperator = errors.New("unknown operator")
+
+// ErrInvalidOperand is returned if the operand of an operator has an unexpected
+// type or structure.
+var ErrInvalidOperand = errors.New("invalid operand")
+
+// ErrUnsupportedSchemaKeyword is returned if a "$jsonSchema" contains a keyword
+// which is valid for MongoDB but can not be represented by Schema.
+var ErrUnsupportedSchemaKeyword = errors.New("unsupported JSON schema keyword")
+
+// FilterError describes an error which occurred while converting a filter into
+// an Expression or while validating an Expression. It wraps ErrUnknownOperator,
+// ErrInvalidOperand or ErrInvalidFieldName.
+type FilterError struct {
+	// Path is the field path (or the logical operator) where the error occurred.
+	Path     string
+	Operator string
+	Err      error
+}
+
+func (fe *FilterError) Error() string {
+	if fe.Path == "" {
+		return fmt.Sprintf("%s %s", fe.Err, fe.Operator)
+	}
+	return fmt.Sprintf("%s %s at %q", fe.Err, fe.Operator, fe.Path)
+}
+
+func (fe *FilterError) Unwrap() error {
+	return fe.Err
+}
+
+var valueQueryOperators = map[string]bool{
+	"$eq": true, "$ne": true, "$gt": true, "$gte": true, "$lt": true, "$lte": true,
+	"$in": true, "$nin": true, "$all": true, "$exists": true, "$size": true, "$type": true,
+	"$regex": true, "$options": true, "$mod": true,
+	"$geoWithin": true, "$geoIntersects": true, "$near": true, "$nearSphere": true,
+	"$maxDistance": true, "$minDistance": true,
+	"$bitsAllSet": true, "$bitsAnySet": true, "$bitsAllClear": true, "$bitsAnyClear": true,
+}
+
+// unsupportedSchemaKeywords are the "$jsonSchema" keywords supported by MongoDB
+// which can not be represented by Schema.
+var unsupportedSchemaKeywords = map[string]bool{
+	"type": true, "title": true, "allOf": true, "anyOf": true, "oneOf": true,
+	"not": true, "multipleOf": true, "minProperties": true, "maxProperties": true,
+	"patternProperties": true, "dependencies": true,
+}
+
+// ParseFilter converts a filter in (Canonical or Relaxed) Extended JSON format
+// into an Expression. See FromBSON for details.
+func ParseFilter(data []byte) (Expression, error) {
+	var d bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &d); err != nil {
+		return Expression{}, err
+	}
+	return FromBSON(d)
+}
+
+// FromBSON converts a filter document into an Expression, so it can be analyzed
+// or combined with other expressions. Multiple conditions at top level are
+// combined with an 'and' condition. Unsupported operators are reported as
+// FilterError.
+func FromBSON(filter bson.D) (Expression, error) {
+	expressions, err := fromDocument(filter)
+	if err != nil {
+		return Expression{}, err
+	}
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
+	return Expression{value: LogicalOperator{operator: "$and", expressions: expressions}}, nil
+}
+
+func fromDocument(document bson.D) ([]Expression, error) {
+	expressions := make([]Expression, 0, len(document))
+	for _, element := range document {
+		expression, err := fromElement(element)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+	return expressions, nil
+}
+
+func fromElement(element bson.E) (Expression, error) {
+	switch element.Key {
+	case "$and", "$or", "$nor":
+		values, ok := toSlice(element.Value)
+		if !ok || len(values) == 0 {
+			return Expression{}, &FilterError{Operator: element.Key, Err: ErrInvalidOperand}
+		}
+		var expressions []Expression
+		for _, value := range values {
+			document, ok := toDocument(value)
+			if !ok {
+				return Expression{}, &FilterError{Operator: element.Key, Err: ErrInvalidOperand}
+			}
+			expression, err := FromBSON(document)
+			if err != nil {
+				return Expression{}, err
+			}
+			expressions = append(expressions, expression)
+		}
+		return Expression{value: LogicalOperator{operator: element.Key, expressions: expressions}}, nil
+	case "$text":
+		document, ok := toDocument(element.Value)
+		if !ok {
+			return Expression{}, &FilterError{Operator: element.Key, Err: ErrInvalidOperand}
+		}
+		return Expression{value: textSearchFromBSON(document)}, nil
+	case "$expr":
+		return Expression{value: AggregationExpression{value: element.Value}}, nil
+	case "$jsonSchema":
+		schema, err := schemaFromBSON(element.Value)
+		if err != nil {
+			return Expression{}, err
+		}
+		return JSONSchema(schema), nil
+	}
+
+	if strings.HasPrefix(element.Key, "$") {
+		return Expression{}, &FilterError{Operator: element.Key, Err: ErrUnknownOperator}
+	}
+
+	field := Field(element.Key)
+	document, ok := toDocument(element.Value)
+	if !ok || !isOperatorDocument(document) {
+		return Expression{field: field, value: element.Value}, nil
+	}
+
+	operators, err := fromOperatorDocument(element.Key, document)
+	if err != nil {
+		return Expression{}, err
+	}
+	if len(operators) == 1 {
+		return Expression{field: field, value: operators[0]}, nil
+	}
+	return Expression{field: field, value: operators}, nil
+}
+
+func fromOperatorDocument(path string, document bson.D) ([]QueryOperator, error) {
+	operators := make([]QueryOperator, 0, len(document))
+	for _, element := range document {
+		operator, err := fromOperator(path, element)
+		if err != nil {
+			return nil, err
+		}
+		operators = append(operators, operator)
+	}
+	return operators, nil
+}
+
+func fromOperator(path string, element bson.E) (QueryOperator, error) {
+	switch element.Key {
+	case "$not":
+		document, ok := toDocument(element.Value)
+		if !ok {
+			// e.g. a regular expression
+			return QueryOperator{operator: element.Key, value: element.Value}, nil
+		}
+		operators, err := fromOperatorDocument(path, document)
+		if err != nil {
+			return QueryOperator{}, err
+		}
+		return QueryOperator{operator: element.Key, value: operators}, nil
+	case "$elemMatch":
+		document, ok := toDocument(element.Value)
+		if !ok {
+			return QueryOperator{}, &FilterError{Path: path, Operator: element.Key, Err: ErrInvalidOperand}
+		}
+		if isOperatorDocument(document) && !isLogicalOperator(document[0].Key) {
+			operators, err := fromOperatorDocument(path, document)
+			if err != nil {
+				return QueryOperator{}, err
+			}
+			return QueryOperator{operator: element.Key, value: operators}, nil
+		}
+		expressions, err := fromDocument(document)
+		if err != nil {
+			return QueryOperator{}, err
+		}
+		return QueryOperator{operator: element.Key, value: expressions}, nil
+	case "$in", "$nin", "$all":
+		values, ok := toSlice(element.Value)
+		if !ok {
+			return QueryOperator{}, &FilterError{Path: path, Operator: element.Key, Err: ErrInvalidOperand}
+		}
+		return QueryOperator{operator: element.Key, value: values}, nil
+	}
+
+	if !valueQueryOperators[element.Key] {
+		return QueryOperator{}, &FilterError{Path: path, Operator: element.Key, Err: ErrUnknownOperator}
+	}
+	return QueryOperator{operator: element.Key, value: element.Value}, nil
+}
+
+func schemaFromBSON(value any) (Schema, error) {
+	document, ok := toDocument(value)
+	if !ok {
+		return Schema{}, &FilterError{Operator: "$jsonSchema", Err: ErrInvalidOperand}
+	}
+
+	invalid := func(keyword string) error {
+		return &FilterError{Path: keyword, Operator: "$jsonSchema", Err: ErrInvalidOperand}
+	}
+
+	schema := Schema{}
+	for _, element := range document {
+		var ok = true
+		switch element.Key {
+		case "bsonType":
+			if name, isString := element.Value.(string); isString {
+				schema.BsonType = []BsonType{BsonType(name)}
+				break
+			}
+			var names []any
+			names, ok = toSlice(element.Value)
+			for _, name := range names {
+				schema.BsonType = append(schema.BsonType, BsonType(fmt.Sprint(name)))
+			}
+		case "description":
+			schema.Description, ok = element.Value.(string)
+		case "required":
+			var names []any
+			names, ok = toSlice(element.Value)
+			for _, name := range names {
+				schema.Required = append(schema.Required, fmt.Sprint(name))
+			}
+		case "properties":
+			var properties bson.D
+			properties, ok = toDocument(element.Value)
+			schema.Properties = make(map[string]Schema)
+			for _, property := range properties {
+				propertySchema, err := schemaFromBSON(property.Value)
+				if err != nil {
+					return Schema{}, err
+				}
+				schema.Properties[property.Key] = propertySchema
+			}
+		case "additionalProperties":
+			var additionalProperties bool
+			additionalProperties, ok = element.Value.(bool)
+			schema.AdditionalProperties = &additionalProperties
+		case "items":
+			items, err := schemaFromBSON(element.Value)
+			if err != nil {
+				return Schema{}, err
+			}
+			schema.Items = &items
+		case "minItems":
+			schema.MinItems, ok = toInt(element.Value)
+		case "maxItems":
+			schema.MaxItems, ok = toInt(element.Value)
+		case "uniqueItems":
+			schema.UniqueItems, ok = element.Value.(bool)
+		case "enum":
+			schema.Enum, ok = toSlice(element.Value)
+		case "minimum":
+			schema.Minimum = element.Value
+		case "maximum":
+			schema.Maximum = element.Value
+		case "exclusiveMinimum":
+			schema.ExclusiveMinimum, ok = element.Value.(bool)
+		case "exclusiveMaximum":
+			schema.ExclusiveMaximum, ok = element.Value.(bool)
+		case "minLength":
+			schema.MinLength, ok = toInt(element.Value)
+		case "maxLength":
+			schema.MaxLength, ok = toInt(element.Value)
+		case "pattern":
+			schema.Pattern, ok = element.Value.(string)
+		default:
+			if unsupportedSchemaKeywords[element.Key] {
+				return Schema{}, &FilterError{Path: element.Key, Operator: "$jsonSchema", Err: ErrUnsupportedSchemaKeyword}
+			}
+			return Schema{}, &FilterError{Path: element.Key, Operator: "$jsonSchema", Err: ErrUnknownOperator}
+		}
+		if !ok {
+			return Schema{}, invalid(element.Key)
+		}
+	}
+	return schema, nil
+}
+
+func isOperatorDocument(document bson.D) bool {
+	return len(document) > 0 && strings.HasPrefix(document[0].Key, "$")
+}
+
+func isLogicalOperator(operator string) bool {
+	return operator == "$and" || operator == "$or" || operator == "$nor"
+}
+
+// toDocument converts the given value to a bson.D if it is a document.
+func toDocument(value any) (bson.D, bool) {
+	switch value.(type) {
+	case bson.D:
+		return value.(bson.D), true
+	case bson.M:
+		m := value.(bson.M)
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		document := bson.D{}
+		for _, key := range keys {
+			document = append(document, bson.E{key, m[key]})
+		}
+		return document, true
+	}
+	return nil, false
+}
+
+// toSlice converts the given value to a []any if it is a slice or an array.
+func toSlice(value any) ([]any, bool) {
+	if values, ok := value.([]any); ok {
+		return values, true
+	}
+	if values, ok := value.(bson.A); ok {
+		return values, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if _, isBinary := value.([]byte); isBinary {
+		return nil, false
+	}
+	values := make([]any, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		values = append(values, rv.Index(i).Interface())
+	}
+	return values, true
+}
+
+func toInt(value any) (int, bool) {
+	switch value.(type) {
+	case int:
+		return value.(int), true
+	case int32:
+		return int(value.(int32)), true
+	case int64:
+		return int(value.(int64)), true
+	case float64:
+		return int(value.(float64)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,209 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+func roundTripTestData() []Expression {
+	var expressions []Expression
+	expressions = append(expressions, nodeTestData...)
+	for _, datum := range nestedLogicalTestData {
+		expressions = append(expressions, datum.filter)
+	}
+	for _, datum := range geoTestData {
+		expressions = append(expressions, datum.filter)
+	}
+	for _, datum := range bitwiseTestData {
+		expressions = append(expressions, datum.filter)
+	}
+	for _, datum := range aggregationTestData {
+		expressions = append(expressions, datum.filter)
+	}
+	return append(expressions,
+		Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Equals("Milo"), Review.ReviewerName.Ne("x")),
+		Listing.Bedrooms.Mod(4, 1),
+		Text("beach", TextCaseSensitive()).And(Listing.Amenities.ArrayContainsAll("Wifi", "Iron")),
+		JSONSchema(Schema{
+			BsonType: []BsonType{BsonTypeObject},
+			Required: []string{"name"},
+			Properties: map[string]Schema{
+				"name":      {BsonType: []BsonType{BsonTypeString}, MinLength: 1, Pattern: "^A"},
+				"amenities": {Items: &Schema{Enum: []any{"Wifi"}}, MaxItems: 3, UniqueItems: true},
+				"price":     {BsonType: []BsonType{BsonTypeNumber, BsonTypeNull}, Minimum: 1, ExclusiveMinimum: true},
+			},
+		}).Nor(),
+	)
+}
+
+func TestFromBSON_roundTrip(t *testing.T) {
+
+	for _, expression := range roundTripTestData() {
+
+		//given
+		original, err := bson.Marshal(expression)
+		if err != nil {
+			t.Errorf("could not marshal expression %v", err)
+			continue
+		}
+
+		//when
+		parsed, err := FromBSON(expression.bsonD())
+		if err != nil {
+			t.Errorf("unexpected error %v for %v", err, expression.bsonD())
+			continue
+		}
+		roundTrip, err := bson.Marshal(parsed)
+
+		//then
+		if err != nil {
+			t.Errorf("could not marshal parsed expression %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(roundTrip, original) {
+			t.Errorf("round trip differs: %v, original: %v", bson.Raw(roundTrip), bson.Raw(original))
+		}
+	}
+
+}
+
+func TestFromBSON_multipleTopLevelConditions(t *testing.T) {
+
+	//given
+	filter := bson.D{{"bedrooms", bson.D{{"$gt", 2}}}, {"name", "A"}}
+
+	//when
+	expression, err := FromBSON(filter)
+
+	//then
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	expected := Listing.Bedrooms.Gt(2).And(Listing.Name.Equals("A"))
+	if !reflect.DeepEqual(expression.bsonD(), expected.bsonD()) {
+		t.Errorf("expected %v but got %v", expected.bsonD(), expression.bsonD())
+	}
+
+}
+
+func TestParseFilter(t *testing.T) {
+
+	//given
+	data := []byte(`{"$or": [
+		{"bedrooms": {"$gt": {"$numberInt": "2"}, "$lte": 4}},
+		{"name": {"$not": {"$regex": "^A", "$options": "i"}}},
+		{"amenities": {"$elemMatch": {"$eq": "Wifi"}}},
+		{"last_scraped": {"$gte": {"$date": "2019-02-16T05:00:00Z"}}}
+	]}`)
+
+	//when
+	expression, err := ParseFilter(data)
+
+	//then
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	var fields []Field
+	var operators []string
+	Walk(expression.Node(), func(node Node) bool {
+		if node.Kind == NodeField {
+			fields = append(fields, node.Field)
+		} else {
+			operators = append(operators, node.Operator)
+		}
+		return true
+	})
+	expectedFields := []Field{"bedrooms", "name", "amenities", "last_scraped"}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected fields %v but got %v", expectedFields, fields)
+	}
+	expectedOperators := []string{"$or", "$gt", "$lte", "$not", "$regex", "$options", "$elemMatch", "$eq", "$gte"}
+	if !reflect.DeepEqual(operators, expectedOperators) {
+		t.Errorf("expected operators %v but got %v", expectedOperators, operators)
+	}
+
+	combined, err := bson.Marshal(expression.And(Listing.Bathrooms.Exists()))
+	if err != nil {
+		t.Errorf("could not marshal combined expression %v", err)
+	}
+	var d bson.D
+	_ = bson.Unmarshal(combined, &d)
+	if d[0].Key != "$and" {
+		t.Errorf("expected combined expression with $and but got %v", d)
+	}
+
+}
+
+var invalidFilterTestData = []struct {
+	testName    string
+	filter      string
+	expectedErr error
+	operator    string
+	path        string
+}{
+	{"unknown top level operator", `{"$where": "this.a > 1"}`, ErrUnknownOperator, "$where", ""},
+	{"unknown field operator", `{"name": {"$foo": 1}}`, ErrUnknownOperator, "$foo", "name"},
+	{"unknown nested operator", `{"$and": [{"name": {"$not": {"$bar": 1}}}]}`, ErrUnknownOperator, "$bar", "name"},
+	{"unknown schema keyword", `{"$jsonSchema": {"foo": []}}`, ErrUnknownOperator, "$jsonSchema", "foo"},
+	{"unsupported schema keyword", `{"$jsonSchema": {"oneOf": []}}`, ErrUnsupportedSchemaKeyword, "$jsonSchema", "oneOf"},
+	{"unsupported nested schema keyword", `{"$jsonSchema": {"properties": {"name": {"title": "Name"}}}}`, ErrUnsupportedSchemaKeyword, "$jsonSchema", "title"},
+	{"and without array", `{"$and": {"name": "A"}}`, ErrInvalidOperand, "$and", ""},
+	{"empty or", `{"$or": []}`, ErrInvalidOperand, "$or", ""},
+	{"in without array", `{"name": {"$in": "A"}}`, ErrInvalidOperand, "$in", "name"},
+}
+
+func TestParseFilter_invalid(t *testing.T) {
+
+	for _, datum := range invalidFilterTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := ParseFilter([]byte(datum.filter))
+
+			//then
+			if !errors.Is(err, datum.expectedErr) {
+				t.Errorf("expected error %v but got: %v", datum.expectedErr, err)
+				return
+			}
+			var filterErr *FilterError
+			if !errors.As(err, &filterErr) {
+				t.Errorf("expected FilterError but got: %T", err)
+				return
+			}
+			if filterErr.Operator != datum.operator || filterErr.Path != datum.path {
+				t.Errorf("expected operator %s at %q but got: %s at %q", datum.operator, datum.path, filterErr.Operator, filterErr.Path)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,229 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Style defines how an Expression or UpdateExpression is rendered as text.
+type Style int
+
+const (
+	// StyleGo renders a Go bson.D literal (e.g. bson.D{{"size.h", bson.D{{"$lt", 15}}}}).
+	// Numbers which are not int32 are written with their type (e.g. int64(15)).
+	// It is used by String().
+	StyleGo Style = iota
+	// StyleMongosh renders the syntax used in the MongoDB shell (e.g. { "size.h": { $lt: 15 } }).
+	// Numbers which are not int32 are written with their type (e.g. Long("15") or Double(15)).
+	StyleMongosh
+	// StyleRelaxedExtJSON renders Relaxed Extended JSON (e.g. {"size.h":{"$lt":15}}).
+	StyleRelaxedExtJSON
+	// StyleCanonicalExtJSON renders Canonical Extended JSON (e.g. {"size.h":{"$lt":{"$numberInt":"15"}}}).
+	StyleCanonicalExtJSON
+)
+
+// Render returns the Expression in the given Style. The order of all keys is
+// preserved, so the output is deterministic.
+func (e Expression) Render(style Style) string {
+	return render(e.bsonD(), style)
+}
+
+// Render returns the UpdateExpression in the given Style. The order of all keys
+// is preserved, so the output is deterministic.
+func (ue UpdateExpression) Render(style Style) string {
+	return render(ue.bsonD(), style)
+}
+
+func render(data bson.D, style Style) string {
+	// marshal and unmarshal to get the BSON types of all values
+	bytes, err := bson.Marshal(data)
+	if err != nil {
+		return fmt.Sprintf("%%!(ERROR=%v)", err)
+	}
+	var doc bson.D
+	if err = bson.Unmarshal(bytes, &doc); err != nil {
+		return fmt.Sprintf("%%!(ERROR=%v)", err)
+	}
+
+	switch style {
+	case StyleMongosh:
+		return renderMongosh(doc)
+	case StyleRelaxedExtJSON, StyleCanonicalExtJSON:
+		extJSON, err := bson.MarshalExtJSON(doc, style == StyleCanonicalExtJSON, false)
+		if err != nil {
+			return fmt.Sprintf("%%!(ERROR=%v)", err)
+		}
+		return string(extJSON)
+	}
+	return renderGo(doc)
+}
+
+func renderGo(value any) string {
+	switch v := value.(type) {
+	case bson.D:
+		var sb strings.Builder
+		sb.WriteString("bson.D{")
+		for i, e := range v {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(fmt.Sprintf("{%q, %s}", e.Key, renderGo(e.Value)))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case bson.A:
+		prefix := "bson.A{"
+		if len(v) > 0 && allDocuments(v) {
+			prefix = "[]bson.D{"
+		}
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		for i, element := range v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(renderGo(element))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "nil"
+	case primitive.DateTime:
+		return fmt.Sprintf("primitive.DateTime(%d)", int64(v))
+	case primitive.Decimal128:
+		high, low := v.GetBytes()
+		return fmt.Sprintf("primitive.NewDecimal128(%d, %d)", high, low)
+	case int32, bool:
+		return fmt.Sprintf("%v", v)
+	case int64:
+		return fmt.Sprintf("int64(%d)", v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "math.NaN()"
+		case math.IsInf(v, 1):
+			return "math.Inf(1)"
+		case math.IsInf(v, -1):
+			return "math.Inf(-1)"
+		case v == math.Trunc(v):
+			// an untyped integer constant would be marshalled as int32
+			return fmt.Sprintf("float64(%s)", strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprintf("%#v", value)
+}
+
+func allDocuments(array bson.A) bool {
+	for _, element := range array {
+		if _, ok := element.(bson.D); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var mongoshIdentifier = regexp.MustCompile(`^\$?[A-Za-z_][A-Za-z0-9_]*$`)
+
+func renderMongosh(value any) string {
+	switch v := value.(type) {
+	case bson.D:
+		if len(v) == 0 {
+			return "{}"
+		}
+		var elements []string
+		for _, e := range v {
+			key := strconv.Quote(e.Key)
+			if mongoshIdentifier.MatchString(e.Key) {
+				key = e.Key
+			}
+			elements = append(elements, key+": "+renderMongosh(e.Value))
+		}
+		return "{ " + strings.Join(elements, ", ") + " }"
+	case bson.A:
+		if len(v) == 0 {
+			return "[]"
+		}
+		var elements []string
+		for _, element := range v {
+			elements = append(elements, renderMongosh(element))
+		}
+		return "[ " + strings.Join(elements, ", ") + " ]"
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "null"
+	case int32, bool:
+		return fmt.Sprintf("%v", v)
+	case int64:
+		return fmt.Sprintf("Long(\"%d\")", v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == math.Trunc(v):
+			// mongosh stores integral numbers as int32
+			return fmt.Sprintf("Double(%s)", strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case primitive.Decimal128:
+		return fmt.Sprintf("Decimal128(%q)", v.String())
+	case primitive.DateTime:
+		return fmt.Sprintf("ISODate(%q)", v.Time().UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	case primitive.ObjectID:
+		return fmt.Sprintf("ObjectId(%q)", v.Hex())
+	case primitive.Regex:
+		return "/" + strings.ReplaceAll(v.Pattern, "/", "\\/") + "/" + v.Options
+	case primitive.Timestamp:
+		return fmt.Sprintf("Timestamp({ t: %d, i: %d })", v.T, v.I)
+	case primitive.Binary:
+		return fmt.Sprintf("BinData(%d, %q)", v.Subtype, base64.StdEncoding.EncodeToString(v.Data))
+	case primitive.MinKey:
+		return "MinKey()"
+	case primitive.MaxKey:
+		return "MaxKey()"
+	case primitive.Undefined:
+		return "undefined"
+	case time.Time:
+		return fmt.Sprintf("ISODate(%q)", v.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	}
+	return fmt.Sprintf("%v", value)
+}
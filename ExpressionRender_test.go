@@ -0,0 +1,127 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func ExampleExpression_Render() {
+
+	f1 := Listing.Bedrooms.Gte(2).And(Listing.Amenities.ArrayContainsAll("Wifi", "Iron"))
+
+	fmt.Println(f1.Render(StyleGo))
+	fmt.Println(f1.Render(StyleMongosh))
+	fmt.Println(f1.Render(StyleRelaxedExtJSON))
+	fmt.Println(f1.Render(StyleCanonicalExtJSON))
+	// Output:
+	// bson.D{{"$and", []bson.D{bson.D{{"bedrooms", bson.D{{"$gte", 2}}}}, bson.D{{"amenities", bson.D{{"$all", bson.A{"Wifi", "Iron"}}}}}}}}
+	// { $and: [ { bedrooms: { $gte: 2 } }, { amenities: { $all: [ "Wifi", "Iron" ] } } ] }
+	// {"$and":[{"bedrooms":{"$gte":2}},{"amenities":{"$all":["Wifi","Iron"]}}]}
+	// {"$and":[{"bedrooms":{"$gte":{"$numberInt":"2"}}},{"amenities":{"$all":["Wifi","Iron"]}}]}
+
+}
+
+func ExampleUpdateExpression_Render() {
+
+	u1 := Listing.Name.Set("Horst").And(Listing.Bedrooms.Inc(1), Listing.ListingUrl.Set("http://www.source-fellows.com"))
+
+	fmt.Println(u1.Render(StyleMongosh))
+	// Output: { $set: { name: "Horst", listing_url: "http://www.source-fellows.com" }, $inc: { bedrooms: 1 } }
+
+}
+
+var renderTestData = []struct {
+	testName string
+	filter   Expression
+	style    Style
+	expected string
+}{
+	{
+		"keeps key order",
+		Listing.Bedrooms.Where(Gt(1), Lt(5), Ne(3)),
+		StyleGo,
+		`bson.D{{"bedrooms", bson.D{{"$gt", 1},{"$lt", 5},{"$ne", 3}}}}`,
+	},
+	{
+		"scalar array",
+		Listing.Bedrooms.In(1, 2),
+		StyleGo,
+		`bson.D{{"bedrooms", bson.D{{"$in", bson.A{1, 2}}}}}`,
+	},
+	{
+		"boolean and null values",
+		Listing.Name.Exists().And(Listing.CleaningFee.Equals(nil)),
+		StyleGo,
+		`bson.D{{"$and", []bson.D{bson.D{{"name", bson.D{{"$exists", true}}}}, bson.D{{"cleaning_fee", nil}}}}}`,
+	},
+	{
+		"mongosh quotes dotted keys",
+		Review.ReviewerName.Regex("^Mi", RegexpOptionCaseInsensitivity),
+		StyleMongosh,
+		`{ "reviews.reviewer_name": { $regex: "^Mi", $options: "i" } }`,
+	},
+	{
+		"mongosh long and double",
+		Listing.Price.Gt(int64(10)).Or(Listing.Price.Lt(2.5)),
+		StyleMongosh,
+		`{ $or: [ { price: { $gt: Long("10") } }, { price: { $lt: 2.5 } } ] }`,
+	},
+	{
+		"go numeric types",
+		Listing.Price.In(int32(2), int64(2), float64(2), 2.5, math.NaN(), math.Inf(-1)),
+		StyleGo,
+		`bson.D{{"price", bson.D{{"$in", bson.A{2, int64(2), float64(2), 2.5, math.NaN(), math.Inf(-1)}}}}}`,
+	},
+	{
+		"mongosh numeric types",
+		Listing.Price.In(int32(2), int64(2), float64(2), 2.5, math.NaN(), math.Inf(1)),
+		StyleMongosh,
+		`{ price: { $in: [ 2, Long("2"), Double(2), 2.5, NaN, Infinity ] } }`,
+	},
+}
+
+func TestExpression_Render(t *testing.T) {
+
+	for _, datum := range renderTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			for i := 0; i < 10; i++ {
+				rendered := datum.filter.Render(datum.style)
+
+				//then
+				if rendered != datum.expected {
+					t.Fatalf("expected %s but got %s", datum.expected, rendered)
+				}
+			}
+
+		})
+	}
+
+}
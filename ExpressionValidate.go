@@ -0,0 +1,129 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidFieldName is returned if a field name of an Expression is empty or
+// starts with "$".
+var ErrInvalidFieldName = errors.New("invalid field name")
+
+// validRegexpOptions contains all options defined as RegexpOption. The lower
+// case "m" is accepted as well because MongoDB uses it for multiline matching.
+var validRegexpOptions = string(RegexpOptionCaseInsensitivity) + string(RegexpOptionMultiline) +
+	string(RegexpOptionExtended) + string(RegexpOptionMatchAll) + "m"
+
+// Validate checks the Expression for constructs which are rejected by MongoDB or
+// silently match nothing:
+//
+//   - a negative "$size", an empty "$in", "$all" or "$type" and a "$not"
+//     without operators
+//   - "$and", "$or" and "$nor" without expressions
+//   - an Expression without field name (e.g. the zero value) or a field name
+//     starting with "$"
+//   - regular expression options which are not a RegexpOption
+//   - a text search within "$or", "$nor" or "$elemMatch" or more than one text
+//     search
+//
+// Errors for operators are of type *FilterError and wrap ErrInvalidOperand.
+// MarshalBSON returns the same errors.
+func (e Expression) Validate() error {
+	if err := e.buildError(); err != nil {
+		return err
+	}
+	if err := e.checkTextPosition(); err != nil {
+		return err
+	}
+	return validateNode(e.Node(), "")
+}
+
+func validateNode(node Node, path string) error {
+	switch node.Kind {
+	case NodeLogical:
+		if len(node.Children) == 0 {
+			return &FilterError{Path: path, Operator: node.Operator, Err: ErrInvalidOperand}
+		}
+	case NodeField:
+		if node.Field == "" || strings.HasPrefix(string(node.Field), "$") {
+			return &FilterError{Path: string(node.Field), Err: ErrInvalidFieldName}
+		}
+		path = string(node.Field)
+		if regex, ok := node.Value.(primitive.Regex); ok && !validRegexpOption(regex.Options) {
+			return &FilterError{Path: path, Operator: "$regex", Err: ErrInvalidOperand}
+		}
+	case NodeOperator:
+		if !validOperand(node) {
+			return &FilterError{Path: path, Operator: node.Operator, Err: ErrInvalidOperand}
+		}
+	}
+
+	for _, child := range node.Children {
+		if err := validateNode(child, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validOperand(node Node) bool {
+	value := node.Value
+	switch node.Operator {
+	case "$size":
+		size, ok := toInt(value)
+		return ok && size >= 0
+	case "$in", "$all", "$type":
+		values, ok := toSlice(value)
+		return !ok || len(values) > 0
+	case "$not":
+		return value != nil || len(node.Children) > 0
+	case "$mod":
+		// MongoDB truncates the divisor to an integer which must not be zero
+		values, ok := toSlice(value)
+		return ok && len(values) == 2 && typeBracket(values[0]) == 2 && math.Trunc(toFloat(values[0])) != 0
+	case "$options":
+		options, ok := value.(string)
+		return ok && validRegexpOption(options)
+	case "$regex":
+		if regex, ok := value.(primitive.Regex); ok {
+			return validRegexpOption(regex.Options)
+		}
+	}
+	return true
+}
+
+func validRegexpOption(options string) bool {
+	for _, option := range options {
+		if !strings.ContainsRune(validRegexpOptions, option) {
+			return false
+		}
+	}
+	return true
+}
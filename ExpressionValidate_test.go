@@ -0,0 +1,124 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var validateTestData = []struct {
+	testName string
+	filter   Expression
+	expected error
+}{
+	{"valid", Listing.Bedrooms.Gt(1).And(Listing.Amenities.ArraySize(0), Listing.Name.Regex("^a", RegexpOptionCaseInsensitivity)), nil},
+	{"negative size", Listing.Amenities.ArraySize(-1), ErrInvalidOperand},
+	{"empty in", Listing.Bedrooms.In(), ErrInvalidOperand},
+	{"empty in within not", Listing.Bedrooms.Not(In()), ErrInvalidOperand},
+	{"empty all", Listing.Amenities.ArrayContainsAll(), ErrInvalidOperand},
+	{"empty type", Listing.Bedrooms.Type(), ErrInvalidOperand},
+	{"not without operators", Listing.Bedrooms.Not(), ErrInvalidOperand},
+	{"not type", Listing.Bedrooms.NotType(BsonTypeInt, BsonTypeLong), nil},
+	{"empty nin is valid", Listing.Bedrooms.NotIn(), nil},
+	{"empty and", Expression{value: LogicalOperator{operator: "$and"}}, ErrInvalidOperand},
+	{"empty or", Listing.Name.Equals("a").And(Expression{value: LogicalOperator{operator: "$or"}}), ErrInvalidOperand},
+	{"zero value", Expression{}, ErrInvalidFieldName},
+	{"zero value within and", Listing.Name.Equals("a").And(Expression{}), ErrInvalidFieldName},
+	{"field starting with $", Field("$where").Equals("a"), ErrInvalidFieldName},
+	{"mod", Listing.Bedrooms.Mod(2, 1), nil},
+	{"mod by zero", Listing.Bedrooms.Mod(0, 1), ErrInvalidOperand},
+	{"mod by truncated zero", Listing.Bedrooms.Mod(0.5, 0), ErrInvalidOperand},
+	{"mod by zero within not", Listing.Bedrooms.Not(Mod(0, 0)), ErrInvalidOperand},
+	{"invalid regex option", Listing.Name.Regex("^a", RegexpOption("g")), ErrInvalidOperand},
+	{"invalid regex option of regex value", Listing.Name.Equals(primitive.Regex{Pattern: "^a", Options: "u"}), ErrInvalidOperand},
+	{"field outside of array in elemMatch", Listing.Reviews.ArrayElemMatchExpression(Listing.Name.Equals("a")), ErrInvalidFieldName},
+	{"array itself in elemMatch", Listing.Reviews.ArrayElemMatchExpression(Listing.Reviews.ArraySize(1)), ErrInvalidFieldName},
+	{"invalid regex option in elemMatch", Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Regex("^a", RegexpOption("q"))), ErrInvalidOperand},
+	{"text within or", Text("coffee").Or(Listing.Name.Equals("a")), ErrTextNotAllowed},
+	{"multiple texts", Text("coffee").And(Text("tea")), ErrMultipleText},
+	{"duplicate operator", Listing.Bedrooms.Where(Gt(1), Gt(2)), ErrDuplicateOperator},
+	{"where without operators", Listing.Bedrooms.Where(), ErrInvalidOperand},
+}
+
+func TestExpression_Validate(t *testing.T) {
+
+	for _, datum := range validateTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			err := datum.filter.Validate()
+
+			//then
+			if datum.expected == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, datum.expected) {
+				t.Errorf("expected %v but got %v", datum.expected, err)
+			}
+
+		})
+	}
+
+}
+
+func TestExpression_MarshalBSON_validates(t *testing.T) {
+
+	for _, datum := range validateTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := bson.Marshal(datum.filter)
+
+			//then
+			if !errors.Is(err, datum.expected) {
+				t.Errorf("expected %v but got %v", datum.expected, err)
+			}
+
+		})
+	}
+
+}
+
+func TestExpression_Validate_errorPath(t *testing.T) {
+
+	//when
+	err := Listing.Name.Equals("a").And(Listing.Amenities.ArraySize(-1)).Validate()
+
+	//then
+	var filterError *FilterError
+	if !errors.As(err, &filterError) {
+		t.Fatalf("expected FilterError but got %v", err)
+	}
+	if filterError.Path != "amenities" || filterError.Operator != "$size" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+}
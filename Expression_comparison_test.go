@@ -25,6 +25,7 @@
 package filter
 
 import (
+	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"reflect"
 	"testing"
@@ -204,3 +205,491 @@ func Test_Compare_ArrayContainsQueryOperatorWithAndCondition(t *testing.T) {
 	}
 
 }
+
+func Test_Compare_Nor(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Gt(1).Nor(Listing.Amenities.ArrayContainsElement(Equals("Wifi")))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$nor", []bson.D{
+		{{"bedrooms", bson.D{{"$gt", 1}}}},
+		{{"amenities", bson.D{{"$eq", "Wifi"}}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_Not(t *testing.T) {
+
+	//given
+	f1 := Listing.Name.Not(Regex("^A"))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"name", bson.D{
+		{"$not", bson.D{{"$regex", "^A"}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_NotWithMultipleOperatorsInAndCondition(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Gt(8).And(Listing.Bedrooms.Not(Gte(2), Lte(9)))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$and", []bson.D{
+		{{"bedrooms", bson.D{{"$gt", 8}}}},
+		{{"bedrooms", bson.D{{"$not", bson.D{{"$gte", 2}, {"$lte", 9}}}}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+var nestedLogicalTestData = []struct {
+	testName  string
+	filter    Expression
+	apiFilter bson.D
+}{
+	{"and with nested or",
+		Listing.Bedrooms.Gt(2).And(Listing.Name.Equals("A").Or(Listing.Name.Equals("B"))),
+		bson.D{{"$and", []bson.D{
+			{{"bedrooms", bson.D{{"$gt", 2}}}},
+			{{"$or", []bson.D{
+				{{"name", "A"}},
+				{{"name", "B"}},
+			}}},
+		}}},
+	},
+	{"or with nested and",
+		Listing.Bedrooms.Gt(2).And(Listing.Name.Equals("A")).Or(Listing.Bedrooms.Lt(1)),
+		bson.D{{"$or", []bson.D{
+			{{"$and", []bson.D{
+				{{"bedrooms", bson.D{{"$gt", 2}}}},
+				{{"name", "A"}},
+			}}},
+			{{"bedrooms", bson.D{{"$lt", 1}}}},
+		}}},
+	},
+	{"nor with nested and/or",
+		Listing.Bedrooms.Gt(2).Nor(Listing.Name.Equals("A").And(Listing.Bedrooms.Lt(1).Or(Listing.Bedrooms.Equals(5)))),
+		bson.D{{"$nor", []bson.D{
+			{{"bedrooms", bson.D{{"$gt", 2}}}},
+			{{"$and", []bson.D{
+				{{"name", "A"}},
+				{{"$or", []bson.D{
+					{{"bedrooms", bson.D{{"$lt", 1}}}},
+					{{"bedrooms", 5}},
+				}}},
+			}}},
+		}}},
+	},
+	{"four levels deep",
+		Listing.Name.Equals("A").And(
+			Listing.Name.Equals("B").Or(
+				Listing.Name.Equals("C").And(
+					Listing.Name.Equals("D").Or(Listing.Name.Equals("E"))))),
+		bson.D{{"$and", []bson.D{
+			{{"name", "A"}},
+			{{"$or", []bson.D{
+				{{"name", "B"}},
+				{{"$and", []bson.D{
+					{{"name", "C"}},
+					{{"$or", []bson.D{
+						{{"name", "D"}},
+						{{"name", "E"}},
+					}}},
+				}}},
+			}}},
+		}}},
+	},
+	{"nested or with array elements",
+		Listing.Bedrooms.Gt(8).And(
+			Listing.Amenities.ArrayContainsElement(Equals("Wifi")).Or(Listing.Amenities.ArraySize(5)),
+			Review.ElementNo(0).ReviewerName.Regex("Mi.*", RegexpOptionCaseInsensitivity)),
+		bson.D{{"$and", []bson.D{
+			{{"bedrooms", bson.D{{"$gt", 8}}}},
+			{{"$or", []bson.D{
+				{{"amenities", bson.D{{"$eq", "Wifi"}}}},
+				{{"amenities", bson.D{{"$size", 5}}}},
+			}}},
+			{{"reviews.0.reviewer_name", bson.D{{"$regex", "Mi.*"}, {"$options", "i"}}}},
+		}}},
+	},
+	{"nested not in or",
+		Listing.Name.Not(Regex("^A")).Or(Listing.Name.Not(Regex("^B")).And(Listing.Bedrooms.Exists())),
+		bson.D{{"$or", []bson.D{
+			{{"name", bson.D{{"$not", bson.D{{"$regex", "^A"}}}}}},
+			{{"$and", []bson.D{
+				{{"name", bson.D{{"$not", bson.D{{"$regex", "^B"}}}}}},
+				{{"bedrooms", bson.D{{"$exists", true}}}},
+			}}},
+		}}},
+	},
+}
+
+func Test_Compare_NestedLogicalOperators(t *testing.T) {
+
+	for _, datum := range nestedLogicalTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//given
+			f1 := datum.filter
+
+			//when
+			mongoFilter := f1.bsonD()
+
+			//then
+			if !reflect.DeepEqual(mongoFilter, datum.apiFilter) {
+				t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, datum.apiFilter)
+			}
+
+		})
+	}
+
+}
+
+func Test_Compare_ArrayElemMatch(t *testing.T) {
+
+	//given
+	f1 := Listing.Amenities.ArrayElemMatch(Regex("^Wi"), Ne("Wifi"))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"amenities", bson.D{
+		{"$elemMatch", bson.D{{"$regex", "^Wi"}, {"$ne", "Wifi"}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_ArrayElemMatchExpression(t *testing.T) {
+
+	//given
+	f1 := Listing.Reviews.ArrayElemMatchExpression(
+		Review.ReviewerName.Equals("Milo"),
+		Review.Comments.Regex("great", RegexpOptionCaseInsensitivity))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"reviews", bson.D{
+		{"$elemMatch", bson.D{
+			{"reviewer_name", "Milo"},
+			{"comments", bson.D{{"$regex", "great"}, {"$options", "i"}}},
+		}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_ArrayElemMatchExpressionWithLogicalOperators(t *testing.T) {
+
+	//given
+	f1 := Listing.Reviews.ArrayElemMatchExpression(
+		Review.ReviewerName.Equals("Milo").Or(Review.ReviewerName.Equals("Mike")),
+		Review.Comments.Exists())
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"reviews", bson.D{
+		{"$elemMatch", bson.D{
+			{"$or", []bson.D{
+				{{"reviewer_name", "Milo"}},
+				{{"reviewer_name", "Mike"}},
+			}},
+			{"comments", bson.D{{"$exists", true}}},
+		}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_Type(t *testing.T) {
+
+	//given
+	f1 := Listing.Bathrooms.Type(BsonTypeString)
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"bathrooms", bson.D{{"$type", "string"}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_NotTypeWithMultipleTypes(t *testing.T) {
+
+	//given
+	f1 := Listing.Bathrooms.NotType(BsonTypeDecimal, BsonTypeNull)
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"bathrooms", bson.D{
+		{"$not", bson.D{{"$type", []string{"decimal", "null"}}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_Mod(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Mod(4, 1)
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"bedrooms", bson.D{{"$mod", []any{4, 1}}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+var betweenTestData = []struct {
+	testName    string
+	inclusivity Inclusivity
+	apiFilter   bson.D
+}{
+	{"include lower", IncludeLower, bson.D{{"bedrooms", bson.D{{"$gte", 2}, {"$lt", 4}}}}},
+	{"include upper", IncludeUpper, bson.D{{"bedrooms", bson.D{{"$gt", 2}, {"$lte", 4}}}}},
+	{"include both", IncludeBoth, bson.D{{"bedrooms", bson.D{{"$gte", 2}, {"$lte", 4}}}}},
+	{"include none", IncludeNone, bson.D{{"bedrooms", bson.D{{"$gt", 2}, {"$lt", 4}}}}},
+}
+
+func Test_Compare_Between(t *testing.T) {
+
+	for _, datum := range betweenTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//given
+			f1 := Listing.Bedrooms.Between(2, 4, datum.inclusivity)
+
+			//when
+			mongoFilter := f1.bsonD()
+
+			//then
+			if !reflect.DeepEqual(mongoFilter, datum.apiFilter) {
+				t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, datum.apiFilter)
+			}
+
+		})
+	}
+
+}
+
+func Test_Compare_BetweenInAndCondition(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Between(2, 4, IncludeBoth).And(Listing.Amenities.ArraySize(15))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$and", []bson.D{
+		{{"bedrooms", bson.D{{"$gte", 2}, {"$lte", 4}}}},
+		{{"amenities", bson.D{{"$size", 15}}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_Where(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Where(Gt(2), Lt(6), Ne(4), Exists())
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"bedrooms", bson.D{{"$gt", 2}, {"$lt", 6}, {"$ne", 4}, {"$exists", true}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Where_duplicateOperator(t *testing.T) {
+
+	//given
+	filters := []Expression{
+		Listing.Bedrooms.Where(Gt(2), Gt(6)),
+		Listing.Name.Exists().And(Listing.Bedrooms.Where(Ne(2), Lt(6), Ne(4))),
+		Listing.Reviews.ArrayElemMatchExpression(Review.ReviewerName.Where(Exists(), NotExists())),
+	}
+
+	for _, f1 := range filters {
+
+		//when
+		_, err := bson.Marshal(f1)
+
+		//then
+		if !errors.Is(err, ErrDuplicateOperator) {
+			t.Errorf("expected error %v but got: %v", ErrDuplicateOperator, err)
+		}
+	}
+
+}
@@ -197,3 +197,90 @@ func ExampleField_Regex_withOptions() {
 	// Output: bson.D{{"size.uom", bson.D{{"$regex", "i.*"},{"$options", "i"}}}}
 
 }
+
+func ExampleExpression_Nor() {
+
+	Filter := struct {
+		Status Field
+		Size   struct {
+			H   Field
+			Uom Field
+		}
+	}{
+		Status: Field("status"),
+		Size: struct {
+			H   Field
+			Uom Field
+		}{
+			Uom: Field("size.uom"),
+			H:   Field("size.h"),
+		},
+	}
+
+	f1 := Filter.Size.H.Lt(15).Nor(Filter.Size.Uom.Equals("in"))
+
+	fmt.Println(f1)
+	// Output: bson.D{{"$nor", []bson.D{bson.D{{"size.h", bson.D{{"$lt", 15}}}}, bson.D{{"size.uom", "in"}}}}}
+
+}
+
+func ExampleField_Not() {
+
+	Filter := struct {
+		Status Field
+		Size   struct {
+			H   Field
+			Uom Field
+		}
+	}{
+		Status: Field("status"),
+		Size: struct {
+			H   Field
+			Uom Field
+		}{
+			Uom: Field("size.uom"),
+			H:   Field("size.h"),
+		},
+	}
+
+	f1 := Filter.Size.Uom.Not(Regex("^i"))
+
+	fmt.Println(f1)
+	// Output: bson.D{{"size.uom", bson.D{{"$not", bson.D{{"$regex", "^i"}}}}}}
+
+}
+
+func ExampleArrayField_ArrayElemMatch() {
+
+	Filter := struct {
+		Results ArrayField
+	}{
+		Results: ArrayField("results"),
+	}
+
+	f1 := Filter.Results.ArrayElemMatch(Gte(80))
+
+	fmt.Println(f1)
+	// Output: bson.D{{"results", bson.D{{"$elemMatch", bson.D{{"$gte", 80}}}}}}
+
+}
+
+func ExampleArrayField_ArrayElemMatchExpression() {
+
+	f1 := Listing.Reviews.ArrayElemMatchExpression(
+		Review.ReviewerName.Equals("Milo"),
+		Review.ElementNo(0).Comments.Exists())
+
+	fmt.Println(f1)
+	// Output: bson.D{{"reviews", bson.D{{"$elemMatch", bson.D{{"reviewer_name", "Milo"},{"comments", bson.D{{"$exists", true}}}}}}}}
+
+}
+
+func ExampleText() {
+
+	f1 := Text("coffee")
+
+	fmt.Println(f1)
+	// Output: bson.D{{"$text", bson.D{{"$search", "coffee"}}}}
+
+}
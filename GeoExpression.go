@@ -0,0 +1,328 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Position represents a GeoJSON position. The first value is the longitude, the
+// second value is the latitude.
+type Position [2]float64
+
+// Pos builds a Position for the given longitude and latitude.
+func Pos(longitude, latitude float64) Position {
+	return Position{longitude, latitude}
+}
+
+func (p Position) validate() error {
+	if !(p[0] >= -180 && p[0] <= 180) {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p[0])
+	}
+	if !(p[1] >= -90 && p[1] <= 90) {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p[1])
+	}
+	return nil
+}
+
+// validateFlat checks the position against the default bounds of a legacy 2d
+// index, which are the same for both coordinates.
+func (p Position) validateFlat() error {
+	for _, coordinate := range p {
+		if !(coordinate >= -180 && coordinate <= 180) {
+			return fmt.Errorf("coordinate %v out of range [-180, 180]", coordinate)
+		}
+	}
+	return nil
+}
+
+// GeoShape is implemented by all shapes which can be used with the "$geoWithin"
+// operator.
+type GeoShape interface {
+	geoShape() bson.E
+}
+
+// GeoJSON is implemented by all GeoJSON geometries. The coordinates of a geometry
+// are validated when it gets marshalled.
+type GeoJSON interface {
+	GeoShape
+	MarshalBSON() ([]byte, error)
+}
+
+// Point represents a GeoJSON object of type "Point".
+type Point struct {
+	Coordinates Position
+}
+
+// LineString represents a GeoJSON object of type "LineString".
+type LineString struct {
+	Coordinates []Position
+}
+
+// Polygon represents a GeoJSON object of type "Polygon". The first ring is the
+// exterior ring, all further rings are interior rings (holes).
+type Polygon struct {
+	Coordinates [][]Position
+}
+
+// MultiPolygon represents a GeoJSON object of type "MultiPolygon".
+type MultiPolygon struct {
+	Coordinates [][][]Position
+}
+
+// MarshalBSON validates and serializes the Point to BSON data.
+func (p Point) MarshalBSON() ([]byte, error) {
+	if err := p.Coordinates.validate(); err != nil {
+		return nil, fmt.Errorf("invalid Point: %w", err)
+	}
+	return bson.Marshal(bson.D{{"type", "Point"}, {"coordinates", p.Coordinates}})
+}
+
+// MarshalBSON validates and serializes the LineString to BSON data.
+func (l LineString) MarshalBSON() ([]byte, error) {
+	if len(l.Coordinates) < 2 {
+		return nil, fmt.Errorf("invalid LineString: needs at least 2 positions but has %d", len(l.Coordinates))
+	}
+	for _, position := range l.Coordinates {
+		if err := position.validate(); err != nil {
+			return nil, fmt.Errorf("invalid LineString: %w", err)
+		}
+	}
+	return bson.Marshal(bson.D{{"type", "LineString"}, {"coordinates", l.Coordinates}})
+}
+
+// MarshalBSON validates and serializes the Polygon to BSON data.
+func (p Polygon) MarshalBSON() ([]byte, error) {
+	if err := validateRings(p.Coordinates); err != nil {
+		return nil, fmt.Errorf("invalid Polygon: %w", err)
+	}
+	return bson.Marshal(bson.D{{"type", "Polygon"}, {"coordinates", p.Coordinates}})
+}
+
+// MarshalBSON validates and serializes the MultiPolygon to BSON data.
+func (m MultiPolygon) MarshalBSON() ([]byte, error) {
+	if len(m.Coordinates) == 0 {
+		return nil, fmt.Errorf("invalid MultiPolygon: needs at least 1 polygon")
+	}
+	for i, polygon := range m.Coordinates {
+		if err := validateRings(polygon); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon: polygon %d: %w", i, err)
+		}
+	}
+	return bson.Marshal(bson.D{{"type", "MultiPolygon"}, {"coordinates", m.Coordinates}})
+}
+
+func validateRings(rings [][]Position) error {
+	if len(rings) == 0 {
+		return fmt.Errorf("needs at least 1 ring")
+	}
+	for i, ring := range rings {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring %d needs at least 4 positions but has %d", i, len(ring))
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("ring %d is not closed - first and last position must be equal", i)
+		}
+		for _, position := range ring {
+			if err := position.validate(); err != nil {
+				return fmt.Errorf("ring %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p Point) geoShape() bson.E {
+	return bson.E{"$geometry", p}
+}
+
+func (l LineString) geoShape() bson.E {
+	return bson.E{"$geometry", l}
+}
+
+func (p Polygon) geoShape() bson.E {
+	return bson.E{"$geometry", p}
+}
+
+func (m MultiPolygon) geoShape() bson.E {
+	return bson.E{"$geometry", m}
+}
+
+// Box represents a legacy rectangle ("$box") defined by its bottom left and
+// upper right corner.
+type Box struct {
+	BottomLeft Position
+	UpperRight Position
+}
+
+// Center represents a legacy circle ("$center") on a flat surface. The radius is
+// measured in the units used by the coordinate system.
+type Center struct {
+	Center Position
+	Radius float64
+}
+
+// CenterSphere represents a legacy circle ("$centerSphere") on a sphere. The radius
+// is measured in radians.
+type CenterSphere struct {
+	Center Position
+	Radius float64
+}
+
+// MarshalBSONValue validates and serializes the corners of the Box to a BSON array.
+func (b Box) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	for _, position := range []Position{b.BottomLeft, b.UpperRight} {
+		if err := position.validateFlat(); err != nil {
+			return 0, nil, fmt.Errorf("invalid Box: %w", err)
+		}
+	}
+	if b.BottomLeft[0] > b.UpperRight[0] || b.BottomLeft[1] > b.UpperRight[1] {
+		return 0, nil, fmt.Errorf("invalid Box: bottom left corner %v is not below and left of upper right corner %v", b.BottomLeft, b.UpperRight)
+	}
+	return bson.MarshalValue([]Position{b.BottomLeft, b.UpperRight})
+}
+
+// MarshalBSONValue validates and serializes the center and the radius of the
+// Center to a BSON array.
+func (c Center) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	err := c.Center.validateFlat()
+	if err == nil {
+		err = validateRadius(c.Radius)
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid Center: %w", err)
+	}
+	return bson.MarshalValue([]any{c.Center, c.Radius})
+}
+
+// MarshalBSONValue validates and serializes the center and the radius of the
+// CenterSphere to a BSON array.
+func (c CenterSphere) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	err := c.Center.validate()
+	if err == nil {
+		err = validateRadius(c.Radius)
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid CenterSphere: %w", err)
+	}
+	return bson.MarshalValue([]any{c.Center, c.Radius})
+}
+
+func validateRadius(radius float64) error {
+	if !(radius > 0) {
+		return fmt.Errorf("radius %v must be positive", radius)
+	}
+	return nil
+}
+
+func (b Box) geoShape() bson.E {
+	return bson.E{"$box", b}
+}
+
+func (c Center) geoShape() bson.E {
+	return bson.E{"$center", c}
+}
+
+func (c CenterSphere) geoShape() bson.E {
+	return bson.E{"$centerSphere", c}
+}
+
+// NearOption is used to limit the results of the "$near" and "$nearSphere" operators.
+type NearOption struct {
+	key   string
+	value float64
+}
+
+// MaxDistance limits the results to documents that are at most the specified
+// distance (in meters) from the center point.
+func MaxDistance(meters float64) NearOption {
+	return NearOption{key: "$maxDistance", value: meters}
+}
+
+// MinDistance limits the results to documents that are at least the specified
+// distance (in meters) from the center point.
+func MinDistance(meters float64) NearOption {
+	return NearOption{key: "$minDistance", value: meters}
+}
+
+// GeoWithin builds a QueryOperator for MongoDB operator "$geoWithin".
+// "geo within" - geometry lies entirely within the given shape
+func GeoWithin(shape GeoShape) QueryOperator {
+	return QueryOperator{operator: "$geoWithin", value: bson.D{shape.geoShape()}}
+}
+
+// GeoIntersects builds a QueryOperator for MongoDB operator "$geoIntersects".
+// "geo intersects" - geometry intersects with the given GeoJSON object
+func GeoIntersects(geometry GeoJSON) QueryOperator {
+	return QueryOperator{operator: "$geoIntersects", value: bson.D{geometry.geoShape()}}
+}
+
+// Near builds a QueryOperator for MongoDB operator "$near".
+// "near" - geometries sorted by their distance to the given point
+func Near(point Point, opts ...NearOption) QueryOperator {
+	return QueryOperator{operator: "$near", value: nearValue(point, opts)}
+}
+
+// NearSphere builds a QueryOperator for MongoDB operator "$nearSphere".
+// "near sphere" - geometries sorted by their spherical distance to the given point
+func NearSphere(point Point, opts ...NearOption) QueryOperator {
+	return QueryOperator{operator: "$nearSphere", value: nearValue(point, opts)}
+}
+
+func nearValue(point Point, opts []NearOption) bson.D {
+	value := bson.D{point.geoShape()}
+	for _, opt := range opts {
+		value = append(value, bson.E{opt.key, opt.value})
+	}
+	return value
+}
+
+// GeoWithin represents a geospatial query operation which selects documents with
+// geospatial data that exists entirely within the specified shape.
+func (f Field) GeoWithin(shape GeoShape) Expression {
+	return Expression{field: f, value: GeoWithin(shape)}
+}
+
+// GeoIntersects represents a geospatial query operation which selects documents
+// whose geospatial data intersects with the specified GeoJSON object.
+func (f Field) GeoIntersects(geometry GeoJSON) Expression {
+	return Expression{field: f, value: GeoIntersects(geometry)}
+}
+
+// Near represents a geospatial query operation which returns documents sorted by
+// their distance to the specified point (nearest to farthest). It requires a
+// geospatial index.
+func (f Field) Near(point Point, opts ...NearOption) Expression {
+	return Expression{field: f, value: Near(point, opts...)}
+}
+
+// NearSphere represents a geospatial query operation which returns documents sorted
+// by their spherical distance to the specified point (nearest to farthest). It
+// requires a geospatial index.
+func (f Field) NearSphere(point Point, opts ...NearOption) Expression {
+	return Expression{field: f, value: NearSphere(point, opts...)}
+}
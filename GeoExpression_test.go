@@ -0,0 +1,266 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Porto (Portugal) - most of the listings in the sample database are located there
+var portoPolygon = Polygon{Coordinates: [][]Position{{
+	Pos(-8.70, 41.10), Pos(-8.55, 41.10), Pos(-8.55, 41.20), Pos(-8.70, 41.20), Pos(-8.70, 41.10),
+}}}
+
+func Test_Compare_GeoWithinPolygon(t *testing.T) {
+
+	//given
+	f1 := Listing.Address.Location.GeoWithin(portoPolygon)
+	apiFilter := bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{{"$geometry", bson.D{
+		{"type", "Polygon"},
+		{"coordinates", bson.A{bson.A{
+			bson.A{-8.70, 41.10}, bson.A{-8.55, 41.10}, bson.A{-8.55, 41.20}, bson.A{-8.70, 41.20}, bson.A{-8.70, 41.10},
+		}}},
+	}}}}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, f1)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	assertSameBSON(t, f1, apiFilter)
+
+}
+
+func Test_Compare_GeoWithinCenterSphere(t *testing.T) {
+
+	//given
+	f1 := Listing.Address.Location.GeoWithin(CenterSphere{Center: Pos(-8.61, 41.15), Radius: 5 / 6378.1})
+	apiFilter := bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{
+		{"$centerSphere", bson.A{bson.A{-8.61, 41.15}, 5 / 6378.1}},
+	}}}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, f1)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	assertSameBSON(t, f1, apiFilter)
+
+}
+
+var geoTestData = []struct {
+	testName  string
+	filter    Expression
+	apiFilter bson.D
+}{
+	{"geoIntersects with line string",
+		Listing.Address.Location.GeoIntersects(LineString{Coordinates: []Position{Pos(-8.6, 41.1), Pos(-8.5, 41.2)}}),
+		bson.D{{"address.location", bson.D{{"$geoIntersects", bson.D{{"$geometry", bson.D{
+			{"type", "LineString"},
+			{"coordinates", bson.A{bson.A{-8.6, 41.1}, bson.A{-8.5, 41.2}}},
+		}}}}}}},
+	},
+	{"geoWithin multi polygon",
+		Listing.Address.Location.GeoWithin(MultiPolygon{Coordinates: [][][]Position{portoPolygon.Coordinates}}),
+		bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{{"$geometry", bson.D{
+			{"type", "MultiPolygon"},
+			{"coordinates", bson.A{bson.A{bson.A{
+				bson.A{-8.70, 41.10}, bson.A{-8.55, 41.10}, bson.A{-8.55, 41.20}, bson.A{-8.70, 41.20}, bson.A{-8.70, 41.10},
+			}}}},
+		}}}}}}},
+	},
+	{"geoWithin box",
+		Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-8.7, 41.1), UpperRight: Pos(-8.5, 41.2)}),
+		bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{
+			{"$box", bson.A{bson.A{-8.7, 41.1}, bson.A{-8.5, 41.2}}},
+		}}}}},
+	},
+	{"geoWithin box with flat coordinates",
+		Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-100, -150), UpperRight: Pos(100, 150)}),
+		bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{
+			{"$box", bson.A{bson.A{-100.0, -150.0}, bson.A{100.0, 150.0}}},
+		}}}}},
+	},
+	{"geoWithin center with flat coordinates",
+		Listing.Address.Location.GeoWithin(Center{Center: Pos(10, 120), Radius: 5}),
+		bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{
+			{"$center", bson.A{bson.A{10.0, 120.0}, 5.0}},
+		}}}}},
+	},
+	{"geoWithin center",
+		Listing.Address.Location.GeoWithin(Center{Center: Pos(-8.6, 41.1), Radius: 0.1}),
+		bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{
+			{"$center", bson.A{bson.A{-8.6, 41.1}, 0.1}},
+		}}}}},
+	},
+	{"near with distances",
+		Listing.Address.Location.Near(Point{Coordinates: Pos(-8.6, 41.1)}, MaxDistance(1000), MinDistance(10)),
+		bson.D{{"address.location", bson.D{{"$near", bson.D{
+			{"$geometry", bson.D{{"type", "Point"}, {"coordinates", bson.A{-8.6, 41.1}}}},
+			{"$maxDistance", 1000.0},
+			{"$minDistance", 10.0},
+		}}}}},
+	},
+	{"nearSphere",
+		Listing.Address.Location.NearSphere(Point{Coordinates: Pos(-8.6, 41.1)}),
+		bson.D{{"address.location", bson.D{{"$nearSphere", bson.D{
+			{"$geometry", bson.D{{"type", "Point"}, {"coordinates", bson.A{-8.6, 41.1}}}},
+		}}}}},
+	},
+	{"geoWithin in and condition",
+		Listing.Bedrooms.Gt(2).And(Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-8.7, 41.1), UpperRight: Pos(-8.5, 41.2)})),
+		bson.D{{"$and", bson.A{
+			bson.D{{"bedrooms", bson.D{{"$gt", 2}}}},
+			bson.D{{"address.location", bson.D{{"$geoWithin", bson.D{
+				{"$box", bson.A{bson.A{-8.7, 41.1}, bson.A{-8.5, 41.2}}},
+			}}}}},
+		}}},
+	},
+}
+
+func Test_Compare_GeoOperators(t *testing.T) {
+
+	for _, datum := range geoTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+			assertSameBSON(t, datum.filter, datum.apiFilter)
+		})
+	}
+
+}
+
+var invalidGeoTestData = []struct {
+	testName string
+	filter   Expression
+}{
+	{"longitude out of range",
+		Listing.Address.Location.Near(Point{Coordinates: Pos(-181, 41.1)}),
+	},
+	{"latitude out of range",
+		Listing.Address.Location.GeoIntersects(LineString{Coordinates: []Position{Pos(-8.6, 41.1), Pos(-8.5, 91)}}),
+	},
+	{"line string with single position",
+		Listing.Address.Location.GeoIntersects(LineString{Coordinates: []Position{Pos(-8.6, 41.1)}}),
+	},
+	{"polygon ring not closed",
+		Listing.Address.Location.GeoWithin(Polygon{Coordinates: [][]Position{{
+			Pos(-8.70, 41.10), Pos(-8.55, 41.10), Pos(-8.55, 41.20), Pos(-8.70, 41.20),
+		}}}),
+	},
+	{"polygon ring too short",
+		Listing.Address.Location.GeoWithin(Polygon{Coordinates: [][]Position{{
+			Pos(-8.70, 41.10), Pos(-8.55, 41.10), Pos(-8.70, 41.10),
+		}}}),
+	},
+	{"empty multi polygon",
+		Listing.Address.Location.GeoWithin(MultiPolygon{}),
+	},
+	{"box corners in wrong order",
+		Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-8.5, 41.2), UpperRight: Pos(-8.7, 41.1)}),
+	},
+	{"box corner out of flat range",
+		Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-8.7, -181), UpperRight: Pos(-8.5, 41.2)}),
+	},
+	{"longitude not a number",
+		Listing.Address.Location.Near(Point{Coordinates: Pos(math.NaN(), 41.1)}),
+	},
+	{"latitude not a number",
+		Listing.Address.Location.GeoWithin(CenterSphere{Center: Pos(-8.6, math.NaN()), Radius: 0.1}),
+	},
+	{"box corner not a number",
+		Listing.Address.Location.GeoWithin(Box{BottomLeft: Pos(-8.7, math.NaN()), UpperRight: Pos(-8.5, 41.2)}),
+	},
+	{"center sphere latitude out of range",
+		Listing.Address.Location.GeoWithin(CenterSphere{Center: Pos(-8.6, 91), Radius: 0.1}),
+	},
+	{"center out of range",
+		Listing.Address.Location.GeoWithin(Center{Center: Pos(181, 41.1), Radius: 0.1}),
+	},
+	{"center with zero radius",
+		Listing.Address.Location.GeoWithin(Center{Center: Pos(-8.6, 41.1)}),
+	},
+	{"center sphere with negative radius",
+		Listing.Address.Location.GeoWithin(CenterSphere{Center: Pos(-8.6, 41.1), Radius: -1}),
+	},
+}
+
+func TestGeoJSON_invalidCoordinates(t *testing.T) {
+
+	for _, datum := range invalidGeoTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := bson.Marshal(datum.filter)
+
+			//then
+			if err == nil {
+				t.Errorf("expected error for invalid coordinates but got none. expression used: %v", datum.filter.bsonD())
+			}
+
+		})
+	}
+
+}
+
+func assertSameBSON(t *testing.T, expression Expression, apiFilter bson.D) {
+	t.Helper()
+
+	mongoBytes, err := bson.Marshal(expression)
+	if err != nil {
+		t.Errorf("could not marshal expression %v", err)
+		return
+	}
+	apiBytes, err := bson.Marshal(apiFilter)
+	if err != nil {
+		t.Errorf("could not marshal api filter %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(mongoBytes, apiBytes) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", bson.Raw(mongoBytes), bson.Raw(apiBytes))
+	}
+}
@@ -0,0 +1,149 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"sort"
+)
+
+// Schema represents a JSON schema (MongoDB dialect) which can be used with the
+// "$jsonSchema" query operator to find documents matching (or not matching) the
+// schema. It can also be used as collection validator, e.g.
+//
+//	options.CreateCollection().SetValidator(JSONSchema(schema))
+//
+// Zero values are omitted when the Schema gets marshalled.
+type Schema struct {
+	// BsonType restricts the value to (one of) the given BSON type(s).
+	BsonType    []BsonType
+	Description string
+	// Required lists the field names an object must contain.
+	Required   []string
+	Properties map[string]Schema
+	// AdditionalProperties disallows fields which are not listed in Properties
+	// if set to false.
+	AdditionalProperties *bool
+	// Items is the schema all elements of an array have to match.
+	Items       *Schema
+	MinItems    int
+	MaxItems    int
+	UniqueItems bool
+	// Enum lists all allowed values.
+	Enum             []any
+	Minimum          any
+	Maximum          any
+	ExclusiveMinimum bool
+	ExclusiveMaximum bool
+	MinLength        int
+	MaxLength        int
+	// Pattern is a regular expression a string value has to match.
+	Pattern string
+}
+
+// JSONSchema represents a query operation which selects the documents that
+// match the given schema. Use it together with Not or Nor to find documents
+// which do not conform to the schema.
+func JSONSchema(schema Schema) Expression {
+	return Expression{value: schema}
+}
+
+// MarshalBSON serializes the Schema to BSON data.
+func (s Schema) MarshalBSON() ([]byte, error) {
+	return bson.Marshal(s.bson())
+}
+
+func (s Schema) bson() bson.D {
+	d := bson.D{}
+	switch len(s.BsonType) {
+	case 0:
+	case 1:
+		d = append(d, bson.E{"bsonType", string(s.BsonType[0])})
+	default:
+		types := make([]string, 0, len(s.BsonType))
+		for _, t := range s.BsonType {
+			types = append(types, string(t))
+		}
+		d = append(d, bson.E{"bsonType", types})
+	}
+	if s.Description != "" {
+		d = append(d, bson.E{"description", s.Description})
+	}
+	if len(s.Required) > 0 {
+		d = append(d, bson.E{"required", s.Required})
+	}
+	if len(s.Properties) > 0 {
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		properties := bson.D{}
+		for _, name := range names {
+			properties = append(properties, bson.E{name, s.Properties[name].bson()})
+		}
+		d = append(d, bson.E{"properties", properties})
+	}
+	if s.AdditionalProperties != nil {
+		d = append(d, bson.E{"additionalProperties", *s.AdditionalProperties})
+	}
+	if s.Items != nil {
+		d = append(d, bson.E{"items", s.Items.bson()})
+	}
+	if s.MinItems > 0 {
+		d = append(d, bson.E{"minItems", s.MinItems})
+	}
+	if s.MaxItems > 0 {
+		d = append(d, bson.E{"maxItems", s.MaxItems})
+	}
+	if s.UniqueItems {
+		d = append(d, bson.E{"uniqueItems", true})
+	}
+	if len(s.Enum) > 0 {
+		d = append(d, bson.E{"enum", s.Enum})
+	}
+	if s.Minimum != nil {
+		d = append(d, bson.E{"minimum", s.Minimum})
+		if s.ExclusiveMinimum {
+			d = append(d, bson.E{"exclusiveMinimum", true})
+		}
+	}
+	if s.Maximum != nil {
+		d = append(d, bson.E{"maximum", s.Maximum})
+		if s.ExclusiveMaximum {
+			d = append(d, bson.E{"exclusiveMaximum", true})
+		}
+	}
+	if s.MinLength > 0 {
+		d = append(d, bson.E{"minLength", s.MinLength})
+	}
+	if s.MaxLength > 0 {
+		d = append(d, bson.E{"maxLength", s.MaxLength})
+	}
+	if s.Pattern != "" {
+		d = append(d, bson.E{"pattern", s.Pattern})
+	}
+	return d
+}
@@ -0,0 +1,127 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+func Test_Compare_JSONSchema(t *testing.T) {
+
+	//given
+	f1 := JSONSchema(Schema{
+		Required: []string{"bathrooms"},
+		Properties: map[string]Schema{
+			"bathrooms": {BsonType: []BsonType{BsonTypeDecimal}},
+			"bedrooms":  {BsonType: []BsonType{BsonTypeInt}, Minimum: 0, Maximum: 10},
+		},
+	}).Nor()
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$nor", []bson.D{
+		{{"$jsonSchema", bson.D{
+			{"required", []string{"bathrooms"}},
+			{"properties", bson.D{
+				{"bathrooms", bson.D{{"bsonType", "decimal"}}},
+				{"bedrooms", bson.D{{"bsonType", "int"}, {"minimum", 0}, {"maximum", 10}}},
+			}},
+		}}},
+	}}}
+
+	//when
+	apiResult, err := query[ListingAndReview](sampleCollection, apiFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+	mongoResult, err := query[ListingAndReview](sampleCollection, mongoFilter)
+	if err != nil {
+		t.Errorf("could not execute query %v", err)
+	}
+
+	//then
+	if !reflect.DeepEqual(mongoResult, apiResult) {
+		t.Errorf("api and generated results differs lib: %v, api: %v", len(mongoResult), len(apiResult))
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_JSONSchemaAllKeywords(t *testing.T) {
+
+	//given
+	additionalProperties := false
+	f1 := JSONSchema(Schema{
+		BsonType:             []BsonType{BsonTypeObject},
+		Description:          "listing",
+		Required:             []string{"name"},
+		AdditionalProperties: &additionalProperties,
+		Properties: map[string]Schema{
+			"name":      {BsonType: []BsonType{BsonTypeString}, MinLength: 1, MaxLength: 100, Pattern: "^[A-Z]"},
+			"room_type": {Enum: []any{"Private room", "Entire home/apt"}},
+			"amenities": {
+				BsonType:    []BsonType{BsonTypeArray},
+				Items:       &Schema{BsonType: []BsonType{BsonTypeString}},
+				MinItems:    1,
+				MaxItems:    50,
+				UniqueItems: true,
+			},
+			"price": {BsonType: []BsonType{BsonTypeNumber, BsonTypeNull}, Minimum: 0, ExclusiveMinimum: true, Maximum: 1000, ExclusiveMaximum: true},
+		},
+	})
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$jsonSchema", bson.D{
+		{"bsonType", "object"},
+		{"description", "listing"},
+		{"required", []string{"name"}},
+		{"properties", bson.D{
+			{"amenities", bson.D{
+				{"bsonType", "array"},
+				{"items", bson.D{{"bsonType", "string"}}},
+				{"minItems", 1},
+				{"maxItems", 50},
+				{"uniqueItems", true},
+			}},
+			{"name", bson.D{{"bsonType", "string"}, {"minLength", 1}, {"maxLength", 100}, {"pattern", "^[A-Z]"}}},
+			{"price", bson.D{
+				{"bsonType", []string{"number", "null"}},
+				{"minimum", 0},
+				{"exclusiveMinimum", true},
+				{"maximum", 1000},
+				{"exclusiveMaximum", true},
+			}},
+			{"room_type", bson.D{{"enum", []any{"Private room", "Entire home/apt"}}}},
+		}},
+		{"additionalProperties", false},
+	}}}
+
+	//then
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
@@ -0,0 +1,222 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrMixedProjection is returned if a Projection includes and excludes fields.
+// Only "_id" can be excluded from a projection which includes fields.
+var ErrMixedProjection = errors.New("projection cannot include and exclude fields")
+
+// ErrDuplicateField is returned if the same field is used more than once.
+var ErrDuplicateField = errors.New("duplicate field")
+
+// ErrPathCollision is returned if a Projection contains a field and one of its
+// embedded fields (e.g. "a" and "a.b") or uses the positional operator and
+// "$elemMatch" for the same array.
+var ErrPathCollision = errors.New("path collision")
+
+// Projection defines the fields returned by a query. It can be used with the
+// find options of the MongoDB API, e.g. options.Find().SetProjection(p).
+//
+// A Projection either includes or excludes fields. Only "_id" can be excluded
+// from a projection which includes fields. "$slice", "$elemMatch" and "$meta"
+// can be combined with both.
+type Projection struct {
+	elements bson.D
+	err      error
+}
+
+// Include returns a Projection which includes the given fields.
+func Include(fields ...FieldPath) Projection {
+	return Projection{}.Include(fields...)
+}
+
+// Exclude returns a Projection which excludes the given fields.
+func Exclude(fields ...FieldPath) Projection {
+	return Projection{}.Exclude(fields...)
+}
+
+// Include adds the given fields to the projection.
+func (p Projection) Include(fields ...FieldPath) Projection {
+	for _, field := range fields {
+		p = p.with(field.fieldPath(), 1)
+	}
+	return p
+}
+
+// Exclude removes the given fields from the projection.
+func (p Projection) Exclude(fields ...FieldPath) Projection {
+	for _, field := range fields {
+		p = p.with(field.fieldPath(), 0)
+	}
+	return p
+}
+
+// Slice limits the number of array elements returned. It is either called with
+// the number of elements (Slice(f, 5) returns the first five elements, Slice(f, -5)
+// the last five) or with the number of elements to skip and the number of
+// elements to return (Slice(f, 20, 10)).
+func (p Projection) Slice(field FieldPath, values ...int) Projection {
+	path := field.fieldPath()
+	switch len(values) {
+	case 1:
+		return p.with(path, bson.D{{"$slice", values[0]}})
+	case 2:
+		if values[1] <= 0 {
+			return p.withError(fmt.Errorf("%w: $slice limit of %q must be positive", ErrInvalidOperand, path))
+		}
+		return p.with(path, bson.D{{"$slice", bson.A{values[0], values[1]}}})
+	}
+	return p.withError(fmt.Errorf("%w: $slice of %q needs one or two values", ErrInvalidOperand, path))
+}
+
+// Positional includes only the first element of the array which matches the
+// query condition on the array (positional "$" operator).
+func (p Projection) Positional(field FieldPath) Projection {
+	return p.with(field.fieldPath()+".$", 1)
+}
+
+// ElemMatch includes only the first element of the array which matches all the
+// given expressions. Like ArrayElemMatchExpression the fields of the generated
+// filter type for the array can be used directly.
+func (p Projection) ElemMatch(field FieldPath, expressions ...Expression) Projection {
+	elemMatch := ArrayField(field.fieldPath()).ArrayElemMatchExpression(expressions...)
+	if err := elemMatch.Validate(); err != nil {
+		return p.withError(err)
+	}
+	return p.with(field.fieldPath(), elemMatch.value.(QueryOperator).bson())
+}
+
+// Meta adds the metadata with the given name (e.g. "textScore") as field to the
+// projection.
+func (p Projection) Meta(field FieldPath, name string) Projection {
+	return p.with(field.fieldPath(), bson.D{{"$meta", name}})
+}
+
+func (p Projection) with(key string, value any) Projection {
+	if p.err != nil {
+		return p
+	}
+	if err := pathCollision(p.elements, key, value); err != nil {
+		return p.withError(err)
+	}
+	p.elements, p.err = appendUnique(p.elements, key, value, "projection")
+	return p
+}
+
+// withError keeps the first error of the projection.
+func (p Projection) withError(err error) Projection {
+	if p.err == nil {
+		p.err = err
+	}
+	return p
+}
+
+// pathCollision returns ErrPathCollision if the key is a parent or an embedded
+// field of a key of the elements. The positional operator is not part of the
+// path, so "a.$" collides with "a" as well.
+func pathCollision(elements bson.D, key string, value any) error {
+	path := strings.TrimSuffix(key, ".$")
+	for _, element := range elements {
+		other := strings.TrimSuffix(element.Key, ".$")
+		switch {
+		case element.Key == key:
+			// reported as ErrDuplicateField
+		case other == path && (isElemMatch(value) || isElemMatch(element.Value)):
+			return fmt.Errorf("%w: positional operator and $elemMatch used for %q", ErrPathCollision, path)
+		case other == path || strings.HasPrefix(path, other+".") || strings.HasPrefix(other, path+"."):
+			return fmt.Errorf("%w: %q and %q", ErrPathCollision, element.Key, key)
+		}
+	}
+	return nil
+}
+
+func isElemMatch(value any) bool {
+	document, ok := value.(bson.D)
+	return ok && len(document) == 1 && document[0].Key == "$elemMatch"
+}
+
+// appendUnique returns a copy of the elements with the new element appended. It
+// returns ErrDuplicateField if the key is already used.
+func appendUnique(elements bson.D, key string, value any, usage string) (bson.D, error) {
+	for _, element := range elements {
+		if element.Key == key {
+			return elements, fmt.Errorf("%w: %q used more than once in %s", ErrDuplicateField, key, usage)
+		}
+	}
+	appended := make(bson.D, 0, len(elements)+1)
+	appended = append(appended, elements...)
+	return append(appended, bson.E{Key: key, Value: value}), nil
+}
+
+// Validate returns the first error of the projection, e.g. ErrMixedProjection.
+func (p Projection) Validate() error {
+	if p.err != nil {
+		return p.err
+	}
+	var included, excluded string
+	for _, element := range p.elements {
+		if element.Key == "_id" {
+			continue
+		}
+		switch {
+		case element.Value == 1 || strings.HasSuffix(element.Key, ".$"):
+			included = element.Key
+		case element.Value == 0:
+			excluded = element.Key
+		}
+		if included != "" && excluded != "" {
+			return fmt.Errorf("%w: %q is included and %q is excluded", ErrMixedProjection, included, excluded)
+		}
+	}
+	return nil
+}
+
+// MarshalBSON serializes the Projection to BSON data.
+func (p Projection) MarshalBSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return bson.Marshal(p.bsonD())
+}
+
+func (p Projection) bsonD() bson.D {
+	if p.elements == nil {
+		return bson.D{}
+	}
+	return p.elements
+}
+
+// String returns the Projection as Go bson.D literal.
+func (p Projection) String() string {
+	return render(p.bsonD(), StyleGo)
+}
@@ -0,0 +1,155 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleInclude() {
+
+	p1 := Include(Listing.Name, Listing.Bedrooms).Exclude(Field("_id")).Slice(Listing.Reviews, 5)
+
+	fmt.Println(p1)
+	// Output: bson.D{{"name", 1},{"bedrooms", 1},{"_id", 0},{"reviews", bson.D{{"$slice", 5}}}}
+
+}
+
+func ExampleProjection_ElemMatch() {
+
+	p1 := Include(Listing.Name).ElemMatch(Listing.Reviews, Review.ReviewerName.Equals("Milo"))
+
+	fmt.Println(p1)
+	// Output: bson.D{{"name", 1},{"reviews", bson.D{{"$elemMatch", bson.D{{"reviewer_name", "Milo"}}}}}}
+
+}
+
+var projectionTestData = []struct {
+	testName   string
+	projection Projection
+	expected   bson.D
+}{
+	{
+		"exclude",
+		Exclude(Listing.Reviews, Listing.Images.PictureUrl),
+		bson.D{{"reviews", 0}, {"images.picture_url", 0}},
+	},
+	{
+		"slice with skip",
+		Exclude(Listing.Images.PictureUrl).Slice(Listing.Reviews, 20, 10),
+		bson.D{{"images.picture_url", 0}, {"reviews", bson.D{{"$slice", bson.A{20, 10}}}}},
+	},
+	{
+		"positional",
+		Include(Listing.Name).Positional(Listing.Reviews),
+		bson.D{{"name", 1}, {"reviews.$", 1}},
+	},
+	{
+		"text score",
+		Include(Listing.Name).Meta(Field("score"), "textScore"),
+		bson.D{{"name", 1}, {"score", bson.D{{"$meta", "textScore"}}}},
+	},
+	{
+		"typed array",
+		Include(Listing.Name).Slice(typedListing.Amenities, 3),
+		bson.D{{"name", 1}, {"amenities", bson.D{{"$slice", 3}}}},
+	},
+	{
+		"sibling fields",
+		Include(Field("address.street"), Field("address.suburb"), Field("addresses")),
+		bson.D{{"address.street", 1}, {"address.suburb", 1}, {"addresses", 1}},
+	},
+	{
+		"empty",
+		Projection{},
+		bson.D{},
+	},
+}
+
+func TestProjection_MarshalBSON(t *testing.T) {
+
+	for _, datum := range projectionTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			actual, err := bson.Marshal(datum.projection)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			//then
+			expected, _ := bson.Marshal(datum.expected)
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("expected %v but got %v", bson.Raw(expected), bson.Raw(actual))
+			}
+
+		})
+	}
+
+}
+
+var projectionErrorTestData = []struct {
+	testName   string
+	projection Projection
+	expected   error
+}{
+	{"include and exclude", Include(Listing.Name).Exclude(Listing.Bedrooms), ErrMixedProjection},
+	{"positional and exclude", Exclude(Listing.Name).Positional(Listing.Reviews), ErrMixedProjection},
+	{"duplicate field", Include(Listing.Name, Listing.Name), ErrDuplicateField},
+	{"slice without values", Include(Listing.Name).Slice(Listing.Reviews), ErrInvalidOperand},
+	{"slice with zero limit", Include(Listing.Name).Slice(Listing.Reviews, 5, 0), ErrInvalidOperand},
+	{"first error is kept by slice", Include(Listing.Name, Listing.Name).Slice(Listing.Amenities, 1, 0), ErrDuplicateField},
+	{"parent and embedded field", Include(Listing.Address.Country, Field("address")), ErrPathCollision},
+	{"embedded and parent field", Exclude(Field("address")).Exclude(Listing.Address.Country), ErrPathCollision},
+	{"include and positional of same array", Include(Listing.Reviews).Positional(Listing.Reviews), ErrPathCollision},
+	{"positional and elemMatch", Include(Listing.Name).Positional(Listing.Reviews).ElemMatch(Listing.Reviews, Review.ReviewerName.Equals("Milo")), ErrPathCollision},
+	{"elemMatch and positional", Include(Listing.Name).ElemMatch(Listing.Reviews, Review.ReviewerName.Equals("Milo")).Positional(Listing.Reviews), ErrPathCollision},
+	{"invalid elemMatch", Include(Listing.Name).ElemMatch(Listing.Reviews, Review.ReviewerName.In()), ErrInvalidOperand},
+}
+
+func TestProjection_MarshalBSON_errors(t *testing.T) {
+
+	for _, datum := range projectionErrorTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := bson.Marshal(datum.projection)
+
+			//then
+			if !errors.Is(err, datum.expected) {
+				t.Errorf("expected %v but got %v", datum.expected, err)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,115 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Sort defines the sort order of a query. It can be used with the find options
+// of the MongoDB API, e.g. options.Find().SetSort(s). The keys keep the order in
+// which they were added, each field may only be used once.
+type Sort struct {
+	elements bson.D
+	err      error
+}
+
+// Asc returns a Sort which sorts by the given field in ascending order.
+func Asc(field FieldPath) Sort {
+	return Sort{}.Asc(field)
+}
+
+// Desc returns a Sort which sorts by the given field in descending order.
+func Desc(field FieldPath) Sort {
+	return Sort{}.Desc(field)
+}
+
+// Asc adds the given field in ascending order.
+func (s Sort) Asc(field FieldPath) Sort {
+	return s.with(field.fieldPath(), 1)
+}
+
+// Desc adds the given field in descending order.
+func (s Sort) Desc(field FieldPath) Sort {
+	return s.with(field.fieldPath(), -1)
+}
+
+// TextScore adds the score of a text search in descending order. The score does
+// not have to be projected (see Projection.Meta), the key "textScore" only names
+// the metadata.
+func (s Sort) TextScore() Sort {
+	return s.with("textScore", TextScore())
+}
+
+func (s Sort) with(key string, value any) Sort {
+	if s.err != nil {
+		return s
+	}
+	s.elements, s.err = appendUnique(s.elements, key, value, "sort")
+	return s
+}
+
+// relativeTo rewrites all keys of the sort relative to an element of the array
+// (see relativePath). Keys outside of the array are reported as
+// ErrInvalidFieldName.
+func (s Sort) relativeTo(array string) (Sort, error) {
+	relative := Sort{err: s.err}
+	for _, element := range s.elements {
+		key, err := relativePath(array, element.Key)
+		if err == nil && key == "" {
+			err = &FilterError{Path: element.Key, Err: ErrInvalidFieldName}
+		}
+		if err != nil {
+			return Sort{}, err
+		}
+		relative.elements = append(relative.elements, bson.E{Key: key, Value: element.Value})
+	}
+	return relative, nil
+}
+
+// Validate returns the first error of the sort, e.g. ErrDuplicateField.
+func (s Sort) Validate() error {
+	return s.err
+}
+
+// MarshalBSON serializes the Sort to BSON data.
+func (s Sort) MarshalBSON() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return bson.Marshal(s.bsonD())
+}
+
+func (s Sort) bsonD() bson.D {
+	if s.elements == nil {
+		return bson.D{}
+	}
+	return s.elements
+}
+
+// String returns the Sort as Go bson.D literal.
+func (s Sort) String() string {
+	return render(s.bsonD(), StyleGo)
+}
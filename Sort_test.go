@@ -0,0 +1,102 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleAsc() {
+
+	s1 := Asc(Listing.Name).Desc(Listing.Bedrooms).Asc(Listing.Reviews)
+
+	fmt.Println(s1)
+	// Output: bson.D{{"name", 1},{"bedrooms", -1},{"reviews", 1}}
+
+}
+
+func ExampleSort_TextScore() {
+
+	s1 := Sort{}.TextScore().Asc(Listing.Name)
+
+	fmt.Println(s1)
+	// Output: bson.D{{"textScore", bson.D{{"$meta", "textScore"}}},{"name", 1}}
+
+}
+
+func TestSort_MarshalBSON(t *testing.T) {
+
+	//given
+	s1 := Desc(Listing.Price).Asc(Listing.ListingUrl)
+
+	//when
+	actual, err := bson.Marshal(s1)
+
+	//then
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, _ := bson.Marshal(bson.D{{"price", -1}, {"listing_url", 1}})
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("expected %v but got %v", bson.Raw(expected), bson.Raw(actual))
+	}
+
+}
+
+func TestSort_MarshalBSON_duplicateKey(t *testing.T) {
+
+	//given
+	s1 := Asc(Listing.Name).Desc(Listing.Bedrooms).Desc(Listing.Name)
+
+	//when
+	_, err := bson.Marshal(s1)
+
+	//then
+	if !errors.Is(err, ErrDuplicateField) {
+		t.Errorf("expected ErrDuplicateField but got %v", err)
+	}
+
+}
+
+func TestSort_immutable(t *testing.T) {
+
+	//given
+	base := Asc(Listing.Name)
+
+	//when
+	s1 := base.Asc(Listing.Bedrooms)
+	s2 := base.Desc(Listing.Bedrooms)
+
+	//then
+	if s1.String() != `bson.D{{"name", 1},{"bedrooms", 1}}` || s2.String() != `bson.D{{"name", 1},{"bedrooms", -1}}` {
+		t.Errorf("unexpected sort %v and %v", s1, s2)
+	}
+
+}
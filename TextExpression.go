@@ -0,0 +1,130 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrTextNotAllowed is returned if a text search is used at a position where
+// MongoDB rejects it.
+var ErrTextNotAllowed = errors.New("$text is only allowed at top level or within $and")
+
+// ErrMultipleText is returned if more than one text search is used in a single
+// query.
+var ErrMultipleText = errors.New("only one $text expression is allowed per query")
+
+// TextOption is used to configure a text search.
+type TextOption struct {
+	key   string
+	value any
+}
+
+// TextLanguage determines the list of stop words for the search and the rules
+// for the stemmer and tokenizer. If not specified, the default language of the
+// index is used.
+func TextLanguage(language string) TextOption {
+	return TextOption{key: "$language", value: language}
+}
+
+// TextCaseSensitive enables case sensitivity for the text search.
+func TextCaseSensitive() TextOption {
+	return TextOption{key: "$caseSensitive", value: true}
+}
+
+// TextDiacriticSensitive enables diacritic sensitivity for the text search.
+func TextDiacriticSensitive() TextOption {
+	return TextOption{key: "$diacriticSensitive", value: true}
+}
+
+type textSearch struct {
+	search string
+	opts   []TextOption
+}
+
+func (ts textSearch) bson() bson.D {
+	value := bson.D{{"$search", ts.search}}
+	for _, opt := range ts.opts {
+		value = append(value, bson.E{opt.key, opt.value})
+	}
+	return bson.D{{"$text", value}}
+}
+
+// Text represents a text search on the content of the fields indexed with a
+// text index. A text search can only be used at top level or within an 'and'
+// condition.
+func Text(search string, opts ...TextOption) Expression {
+	return Expression{value: textSearch{search: search, opts: opts}}
+}
+
+// TextScore returns the metadata expression for the score of a text search. Use
+// Projection.Meta to project and Sort.TextScore to sort by the score.
+func TextScore() bson.D {
+	return bson.D{{"$meta", "textScore"}}
+}
+
+// checkTextPosition makes sure there is at most one text search which is not
+// nested within an operator other than "$and".
+func (e Expression) checkTextPosition() error {
+	count := 0
+	return e.checkTextPositionRecursive(true, &count)
+}
+
+func (e Expression) checkTextPositionRecursive(allowed bool, count *int) error {
+	switch e.value.(type) {
+	case textSearch:
+		*count++
+		if !allowed {
+			return ErrTextNotAllowed
+		}
+		if *count > 1 {
+			return ErrMultipleText
+		}
+	case LogicalOperator:
+		lo := e.value.(LogicalOperator)
+		for _, expression := range lo.expressions {
+			if err := expression.checkTextPositionRecursive(allowed && lo.operator == "$and", count); err != nil {
+				return err
+			}
+		}
+	case []Expression:
+		return checkTextPositionNotAllowed(e.value.([]Expression), count)
+	case QueryOperator:
+		if expressions, ok := e.value.(QueryOperator).value.([]Expression); ok {
+			return checkTextPositionNotAllowed(expressions, count)
+		}
+	}
+	return nil
+}
+
+func checkTextPositionNotAllowed(expressions []Expression, count *int) error {
+	for _, expression := range expressions {
+		if err := expression.checkTextPositionRecursive(false, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+func Test_Compare_Text(t *testing.T) {
+
+	//given
+	f1 := Text("beach house", TextLanguage("en"), TextCaseSensitive(), TextDiacriticSensitive())
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$text", bson.D{
+		{"$search", "beach house"},
+		{"$language", "en"},
+		{"$caseSensitive", true},
+		{"$diacriticSensitive", true},
+	}}}
+
+	//then
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_TextInAndCondition(t *testing.T) {
+
+	//given
+	f1 := Listing.Bedrooms.Gt(2).And(Text("beach"))
+	mongoFilter := f1.bsonD()
+	apiFilter := bson.D{{"$and", []bson.D{
+		{{"bedrooms", bson.D{{"$gt", 2}}}},
+		{{"$text", bson.D{{"$search", "beach"}}}},
+	}}}
+
+	//when
+	_, err := bson.Marshal(f1)
+
+	//then
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(mongoFilter, apiFilter) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", mongoFilter, apiFilter)
+	}
+
+}
+
+func Test_Compare_TextScore(t *testing.T) {
+
+	//given
+	projection := Projection{}.Meta(Field("score"), "textScore")
+	sort := Sort{}.TextScore()
+
+	//then
+	apiProjection := bson.D{{"score", bson.D{{"$meta", "textScore"}}}}
+	if !reflect.DeepEqual(projection.bsonD(), apiProjection) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", projection.bsonD(), apiProjection)
+	}
+	apiSort := bson.D{{"textScore", bson.D{{"$meta", "textScore"}}}}
+	if !reflect.DeepEqual(sort.bsonD(), apiSort) {
+		t.Errorf("mongoquery and api value differs lib: %v, api: %v", sort.bsonD(), apiSort)
+	}
+
+}
+
+var invalidTextTestData = []struct {
+	testName    string
+	filter      Expression
+	expectedErr error
+}{
+	{"text in or condition",
+		Listing.Bedrooms.Gt(2).Or(Text("beach")),
+		ErrTextNotAllowed,
+	},
+	{"text in nor condition",
+		Text("beach").Nor(Listing.Bedrooms.Gt(2)),
+		ErrTextNotAllowed,
+	},
+	{"text in and within or condition",
+		Listing.Bedrooms.Gt(2).Or(Listing.Name.Exists().And(Text("beach"))),
+		ErrTextNotAllowed,
+	},
+	{"text in element match",
+		Listing.Reviews.ArrayElemMatchExpression(Text("beach")),
+		ErrTextNotAllowed,
+	},
+	{"multiple text expressions",
+		Text("beach").And(Text("house")),
+		ErrMultipleText,
+	},
+}
+
+func TestText_invalidPosition(t *testing.T) {
+
+	for _, datum := range invalidTextTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := bson.Marshal(datum.filter)
+
+			//then
+			if !errors.Is(err, datum.expectedErr) {
+				t.Errorf("expected error %v but got: %v", datum.expectedErr, err)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,295 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// TypedField represents a single field in a BSON document whose values are of
+// type T. In contrast to Field the values given to the operations are checked at
+// compile time. It renders exactly like Field.
+type TypedField[T any] string
+
+// TypedArrayField represents an array field in a BSON document whose elements
+// are of type T. In contrast to ArrayField the values given to the operations are
+// checked at compile time. It renders exactly like ArrayField.
+type TypedArrayField[T any] string
+
+func toAny[T any](values []T) []any {
+	anyValues := make([]any, 0, len(values))
+	for _, value := range values {
+		anyValues = append(anyValues, value)
+	}
+	return anyValues
+}
+
+// Field returns the untyped Field, e.g. to use operations which are not
+// available on TypedField.
+func (f TypedField[T]) Field() Field {
+	return Field(f)
+}
+
+func (f TypedField[T]) fieldPath() string {
+	return string(f)
+}
+
+// Equals represents a query operation for 'equals' comparison.
+func (f TypedField[T]) Equals(value T) Expression {
+	return Field(f).Equals(value)
+}
+
+// Gt represents a query operation for 'greater than' comparison.
+func (f TypedField[T]) Gt(value T) Expression {
+	return Field(f).Gt(value)
+}
+
+// Gte represents a query operation for 'greater than or equals' comparison.
+func (f TypedField[T]) Gte(value T) Expression {
+	return Field(f).Gte(value)
+}
+
+// Lt represents a query operation for 'less than' comparison.
+func (f TypedField[T]) Lt(value T) Expression {
+	return Field(f).Lt(value)
+}
+
+// Lte represents a query operation for 'less than or equal' comparison.
+func (f TypedField[T]) Lte(value T) Expression {
+	return Field(f).Lte(value)
+}
+
+// Ne represents a query operation for 'not equals' comparison.
+func (f TypedField[T]) Ne(value T) Expression {
+	return Field(f).Ne(value)
+}
+
+// Between represents a query operation for a range comparison.
+func (f TypedField[T]) Between(lower, upper T, inclusivity Inclusivity) Expression {
+	return Field(f).Between(lower, upper, inclusivity)
+}
+
+// In represents a query operation for 'in' comparison.
+func (f TypedField[T]) In(values ...T) Expression {
+	return Field(f).In(toAny(values)...)
+}
+
+// NotIn represents a query operation for 'not in' comparison.
+func (f TypedField[T]) NotIn(values ...T) Expression {
+	return Field(f).NotIn(toAny(values)...)
+}
+
+// Exists represents an element query operation to check if a field exists.
+func (f TypedField[T]) Exists() Expression {
+	return Field(f).Exists()
+}
+
+// NotExists represents an element query operation to check if a field does not exist.
+func (f TypedField[T]) NotExists() Expression {
+	return Field(f).NotExists()
+}
+
+// Set replaces the value of the field with the specified value.
+func (f TypedField[T]) Set(value T) UpdateExpression {
+	return Field(f).Set(value)
+}
+
+// Min updates the value of the field to a specified value if the specified value
+// is less than the current value of the field.
+func (f TypedField[T]) Min(value T) UpdateExpression {
+	return Field(f).Min(value)
+}
+
+// Max updates the value of the field to a specified value if the specified value is
+// greater than the current value of the field.
+func (f TypedField[T]) Max(value T) UpdateExpression {
+	return Field(f).Max(value)
+}
+
+// Rename updates the name of the field.
+func (f TypedField[T]) Rename(value TypedField[T]) UpdateExpression {
+	return Field(f).Rename(Field(value))
+}
+
+// Unset deletes the particular field.
+func (f TypedField[T]) Unset() UpdateExpression {
+	return Field(f).Unset()
+}
+
+// SetOnInsert sets the value of the field only if an update with the option
+// upsert inserts a new document.
+func (f TypedField[T]) SetOnInsert(value T) UpdateExpression {
+	return Field(f).SetOnInsert(value)
+}
+
+// CurrentDate sets the value of a field to the current date as a Date.
+func (f TypedField[T]) CurrentDate() UpdateExpression {
+	return Field(f).CurrentDate()
+}
+
+// CurrentDateOfType sets the value of a field to the current date, either as a
+// Date or a timestamp (see Field.CurrentDateOfType).
+func (f TypedField[T]) CurrentDateOfType(dateType DateType) UpdateExpression {
+	return Field(f).CurrentDateOfType(dateType)
+}
+
+// Assign returns an Assignment of the given value to the field (see
+// Field.Assign).
+func (f TypedField[T]) Assign(value any) Assignment {
+	return Field(f).Assign(value)
+}
+
+// Number is the constraint for the types of typed fields which can be updated
+// arithmetically.
+type Number interface {
+	Integer | ~uint8 | ~uint16 | ~uint32 | ~float32 | ~float64 | primitive.Decimal128
+}
+
+// Integer is the constraint for the types of typed fields which can be updated
+// bitwise.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Inc increments the numeric field by a specified value.
+func Inc[T Number](f TypedField[T], value T) UpdateExpression {
+	return Field(f).Inc(value)
+}
+
+// Mul multiplies the value of the numeric field by a number.
+func Mul[T Number](f TypedField[T], value T) UpdateExpression {
+	return Field(f).Mul(value)
+}
+
+// BitAnd updates the integer field to the result of a bitwise "and" of its
+// value and the given integer.
+func BitAnd[T Integer](f TypedField[T], value T) UpdateExpression {
+	return Field(f).BitAnd(value)
+}
+
+// BitOr updates the integer field to the result of a bitwise "or" of its value
+// and the given integer.
+func BitOr[T Integer](f TypedField[T], value T) UpdateExpression {
+	return Field(f).BitOr(value)
+}
+
+// BitXor updates the integer field to the result of a bitwise "xor" of its
+// value and the given integer.
+func BitXor[T Integer](f TypedField[T], value T) UpdateExpression {
+	return Field(f).BitXor(value)
+}
+
+// ArrayField returns the untyped ArrayField, e.g. to use operations which are
+// not available on TypedArrayField.
+func (f TypedArrayField[T]) ArrayField() ArrayField {
+	return ArrayField(f)
+}
+
+func (f TypedArrayField[T]) fieldPath() string {
+	return string(f)
+}
+
+// ArrayContainsAll matches all documents where the given values are in the array.
+func (f TypedArrayField[T]) ArrayContainsAll(values ...T) Expression {
+	return ArrayField(f).ArrayContainsAll(toAny(values)...)
+}
+
+// ArrayContainsExact matches all documents where ONLY the given values are in the array.
+func (f TypedArrayField[T]) ArrayContainsExact(values ...T) Expression {
+	return ArrayField(f).ArrayContainsExact(toAny(values)...)
+}
+
+// ArrayContains matches all documents where the array contains the given value.
+// In contrast to ArrayField.ArrayContainsElement it is an equality match.
+func (f TypedArrayField[T]) ArrayContains(value T) Expression {
+	return Expression{field: Field(f), value: value}
+}
+
+// ArrayElemMatch matches all documents where at least one element of the array
+// satisfies all the given operators.
+func (f TypedArrayField[T]) ArrayElemMatch(queries ...QueryOperator) Expression {
+	return ArrayField(f).ArrayElemMatch(queries...)
+}
+
+// ArrayElemMatchExpression matches all documents where at least one embedded
+// document of the array satisfies all the given expressions.
+func (f TypedArrayField[T]) ArrayElemMatchExpression(expressions ...Expression) Expression {
+	return ArrayField(f).ArrayElemMatchExpression(expressions...)
+}
+
+// ArraySize matches all documents where the array has the given size.
+func (f TypedArrayField[T]) ArraySize(size int) Expression {
+	return ArrayField(f).ArraySize(size)
+}
+
+// Set replaces the array with the specified values.
+func (f TypedArrayField[T]) Set(values []T) UpdateExpression {
+	return Field(f).Set(values)
+}
+
+// Unset deletes the particular field.
+func (f TypedArrayField[T]) Unset() UpdateExpression {
+	return Field(f).Unset()
+}
+
+// Assign returns an Assignment of the given value to the array field (see
+// Field.Assign).
+func (f TypedArrayField[T]) Assign(value any) Assignment {
+	return ArrayField(f).Assign(value)
+}
+
+// Push appends the given value(s) to the array.
+func (f TypedArrayField[T]) Push(values ...T) UpdateExpression {
+	return ArrayField(f).Push(toAny(values)...)
+}
+
+// PushEach appends the given values to the array (see ArrayField.PushEach).
+func (f TypedArrayField[T]) PushEach(values []T, modifiers ...PushModifier) UpdateExpression {
+	return ArrayField(f).PushEach(toAny(values), modifiers...)
+}
+
+// AddToSet adds the given value(s) to the array unless they are already present.
+func (f TypedArrayField[T]) AddToSet(values ...T) UpdateExpression {
+	return ArrayField(f).AddToSet(toAny(values)...)
+}
+
+// Pop removes the first or last element of the array.
+func (f TypedArrayField[T]) Pop(position PopPosition) UpdateExpression {
+	return ArrayField(f).Pop(position)
+}
+
+// Pull removes all elements of the array which satisfy the given operators.
+func (f TypedArrayField[T]) Pull(operators ...QueryOperator) UpdateExpression {
+	return ArrayField(f).Pull(operators...)
+}
+
+// PullExpression removes all embedded documents of the array which satisfy all
+// the given expressions.
+func (f TypedArrayField[T]) PullExpression(expressions ...Expression) UpdateExpression {
+	return ArrayField(f).PullExpression(expressions...)
+}
+
+// PullAll removes all instances of the given values from the array.
+func (f TypedArrayField[T]) PullAll(values ...T) UpdateExpression {
+	return ArrayField(f).PullAll(toAny(values)...)
+}
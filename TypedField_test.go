@@ -0,0 +1,121 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var typedListing = struct {
+	Name        TypedField[string]
+	Bedrooms    TypedField[int]
+	LastScraped TypedField[time.Time]
+	Amenities   TypedArrayField[string]
+}{
+	Name:        TypedField[string]("name"),
+	Bedrooms:    TypedField[int]("bedrooms"),
+	LastScraped: TypedField[time.Time]("last_scraped"),
+	Amenities:   TypedArrayField[string]("amenities"),
+}
+
+var typedFieldTestData = []struct {
+	testName string
+	typed    Expression
+	untyped  Expression
+}{
+	{"equals", typedListing.Name.Equals("x"), Listing.Name.Equals("x")},
+	{"gt", typedListing.Bedrooms.Gt(2), Listing.Bedrooms.Gt(2)},
+	{"gte", typedListing.Bedrooms.Gte(2), Listing.Bedrooms.Gte(2)},
+	{"lt", typedListing.Bedrooms.Lt(2), Listing.Bedrooms.Lt(2)},
+	{"lte", typedListing.Bedrooms.Lte(2), Listing.Bedrooms.Lte(2)},
+	{"ne", typedListing.Bedrooms.Ne(2), Listing.Bedrooms.Ne(2)},
+	{"between", typedListing.Bedrooms.Between(2, 4, IncludeBoth), Listing.Bedrooms.Between(2, 4, IncludeBoth)},
+	{"in", typedListing.Name.In("x", "y"), Listing.Name.In("x", "y")},
+	{"not in", typedListing.Name.NotIn("x", "y"), Listing.Name.NotIn("x", "y")},
+	{"exists", typedListing.Name.Exists(), Listing.Name.Exists()},
+	{"not exists", typedListing.Name.NotExists(), Listing.Name.NotExists()},
+	{"untyped field", typedListing.Name.Field().Regex("^A"), Listing.Name.Regex("^A")},
+	{"array contains all", typedListing.Amenities.ArrayContainsAll("Wifi", "Iron"), Listing.Amenities.ArrayContainsAll("Wifi", "Iron")},
+	{"array contains exact", typedListing.Amenities.ArrayContainsExact("Wifi"), Listing.Amenities.ArrayContainsExact("Wifi")},
+	{"array contains", typedListing.Amenities.ArrayContains("Wifi"), Field("amenities").Equals("Wifi")},
+	{"array elem match", typedListing.Amenities.ArrayElemMatch(Regex("^Wi")), Listing.Amenities.ArrayElemMatch(Regex("^Wi"))},
+	{"array size", typedListing.Amenities.ArraySize(3), Listing.Amenities.ArraySize(3)},
+}
+
+func TestTypedField_rendersLikeField(t *testing.T) {
+
+	for _, datum := range typedFieldTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+			if !reflect.DeepEqual(datum.typed.bsonD(), datum.untyped.bsonD()) {
+				t.Errorf("typed and untyped value differs typed: %v, untyped: %v", datum.typed.bsonD(), datum.untyped.bsonD())
+			}
+		})
+	}
+
+}
+
+var typedUpdateTestData = []struct {
+	testName string
+	typed    UpdateExpression
+	untyped  UpdateExpression
+}{
+	{"set", typedListing.Name.Set("x"), Listing.Name.Set("x")},
+	{"inc", Inc(typedListing.Bedrooms, 1), Listing.Bedrooms.Inc(1)},
+	{"min", typedListing.Bedrooms.Min(1), Listing.Bedrooms.Min(1)},
+	{"max", typedListing.Bedrooms.Max(1), Listing.Bedrooms.Max(1)},
+	{"mul", Mul(typedListing.Bedrooms, 2), Listing.Bedrooms.Mul(2)},
+	{"rename", typedListing.Name.Rename("title"), Listing.Name.Rename("title")},
+	{"unset", typedListing.Name.Unset(), Listing.Name.Unset()},
+	{"current date", typedListing.LastScraped.CurrentDate(), Listing.LastScraped.CurrentDate()},
+	{"current date of type", typedListing.LastScraped.CurrentDateOfType(DateTypeTimestamp), Listing.LastScraped.CurrentDateOfType(DateTypeTimestamp)},
+	{"set on insert", typedListing.Name.SetOnInsert("x"), Listing.Name.SetOnInsert("x")},
+	{"bit and", BitAnd(typedListing.Bedrooms, 1), Field("bedrooms").BitAnd(1)},
+	{"bit or", BitOr(typedListing.Bedrooms, 1), Field("bedrooms").BitOr(1)},
+	{"bit xor", BitXor(typedListing.Bedrooms, 1), Field("bedrooms").BitXor(1)},
+	{"set array", typedListing.Amenities.Set([]string{"Wifi"}), Field("amenities").Set([]string{"Wifi"})},
+	{"unset array", typedListing.Amenities.Unset(), Field("amenities").Unset()},
+	{"push", typedListing.Amenities.Push("Wifi"), Listing.Amenities.Push("Wifi")},
+	{"push each", typedListing.Amenities.PushEach([]string{"Wifi"}, PushSlice(5)), Listing.Amenities.PushEach([]any{"Wifi"}, PushSlice(5))},
+	{"add to set", typedListing.Amenities.AddToSet("Wifi", "Pool"), Listing.Amenities.AddToSet("Wifi", "Pool")},
+	{"pop", typedListing.Amenities.Pop(PopFirst), Listing.Amenities.Pop(PopFirst)},
+	{"pull", typedListing.Amenities.Pull(Equals("Wifi")), Listing.Amenities.Pull(Equals("Wifi"))},
+	{"pull all", typedListing.Amenities.PullAll("Wifi"), Listing.Amenities.PullAll("Wifi")},
+}
+
+func TestTypedField_updatesRenderLikeField(t *testing.T) {
+
+	for _, datum := range typedUpdateTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+			if !reflect.DeepEqual(datum.typed.bsonD(), datum.untyped.bsonD()) {
+				t.Errorf("typed and untyped value differs typed: %v, untyped: %v", datum.typed.bsonD(), datum.untyped.bsonD())
+			}
+		})
+	}
+
+}
@@ -25,17 +25,108 @@
 package filter
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
 
-type fullUpdateOperator map[string]bson.D
+// ErrUpdateConflict is returned if an update modifies a path more than once or
+// modifies a path and one of its parents.
+var ErrUpdateConflict = errors.New("conflicting update paths")
+
+// fullUpdateOperator collects the fields of several update operators. The
+// operators keep the order in which they were added.
+type fullUpdateOperator struct {
+	operators []string
+	values    map[string]bson.D
+	paths     []string
+}
+
+func (fuo *fullUpdateOperator) add(uo UpdateOperator) error {
+	if fuo.values == nil {
+		fuo.values = map[string]bson.D{}
+	}
+	for i, e := range fuo.values[uo.operator] {
+		if e.Key != string(uo.field) {
+			continue
+		}
+		merged, ok := mergeUpdateValues(uo.operator, e.Value, uo.value)
+		if !ok {
+			return fmt.Errorf("%w: %s of field %q used more than once", ErrUpdateConflict, uo.operator, uo.field)
+		}
+		fuo.values[uo.operator][i].Value = merged
+		return nil
+	}
+	paths := uo.paths()
+	for _, path := range paths {
+		for _, existing := range fuo.paths {
+			if conflictingPaths(path, existing) {
+				return fmt.Errorf("%w: %q and %q", ErrUpdateConflict, existing, path)
+			}
+		}
+	}
+	fuo.paths = append(fuo.paths, paths...)
+	if _, ok := fuo.values[uo.operator]; !ok {
+		fuo.operators = append(fuo.operators, uo.operator)
+	}
+	fuo.values[uo.operator] = append(fuo.values[uo.operator], uo.bsonWithoutOperator())
+	return nil
+}
+
+// mergeUpdateValues merges the values of an update operator which is used twice
+// for the same field. Only several bitwise operations and the values of "$push"
+// and "$addToSet" without modifiers can be merged.
+func mergeUpdateValues(operator string, existing, value any) (any, bool) {
+	switch operator {
+	case "$bit":
+		// several bitwise operations of the same field share one document, but
+		// each operation may only be used once
+		operations := append(bson.D{}, existing.(bson.D)...)
+		for _, operation := range value.(bson.D) {
+			for _, e := range operations {
+				if e.Key == operation.Key {
+					return nil, false
+				}
+			}
+			operations = append(operations, operation)
+		}
+		return operations, true
+	case "$push", "$addToSet":
+		existingValues, ok := eachValues(existing)
+		if !ok {
+			return nil, false
+		}
+		values, ok := eachValues(value)
+		if !ok {
+			return nil, false
+		}
+		return bson.D{{"$each", append(append([]any{}, existingValues...), values...)}}, true
+	}
+	return nil, false
+}
+
+// eachValues returns the values added by "$push" or "$addToSet". It reports false
+// if the values are used with modifiers.
+func eachValues(value any) ([]any, bool) {
+	if d, ok := value.(bson.D); ok && len(d) > 0 && d[0].Key == "$each" {
+		values, ok := d[0].Value.([]any)
+		return values, ok && len(d) == 1
+	}
+	return []any{value}, true
+}
+
+// conflictingPaths reports if both paths are equal or one of them is the parent
+// of the other.
+func conflictingPaths(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
 
 func (fuo fullUpdateOperator) bson() bson.D {
 	values := bson.D{}
-	for operator, ops := range fuo {
-		values = append(values, bson.E{Key: operator, Value: ops})
+	for _, operator := range fuo.operators {
+		values = append(values, bson.E{Key: operator, Value: fuo.values[operator]})
 	}
 	return values
 }
@@ -54,6 +145,15 @@ func (uo UpdateOperator) bsonWithoutOperator() bson.E {
 	return bson.E{Key: string(uo.field), Value: uo.value}
 }
 
+// paths returns the paths modified by the operator. "$rename" modifies the
+// field and the new field.
+func (uo UpdateOperator) paths() []string {
+	if uo.operator == "$rename" {
+		return []string{string(uo.field), uo.value.(string)}
+	}
+	return []string{string(uo.field)}
+}
+
 func set(field Field, value any) UpdateOperator {
 	return UpdateOperator{operator: "$set", field: field, value: value}
 }
@@ -86,8 +186,41 @@ func currentDate(field Field) UpdateOperator {
 	return UpdateOperator{operator: "$currentDate", field: field, value: true}
 }
 
+func currentDateOfType(field Field, dateType DateType) UpdateOperator {
+	return UpdateOperator{operator: "$currentDate", field: field, value: bson.D{{"$type", string(dateType)}}}
+}
+
+func bit(field Field, operation string, value any) UpdateOperator {
+	return UpdateOperator{operator: "$bit", field: field, value: bson.D{{operation, value}}}
+}
+
+func setOnInsert(field Field, value any) UpdateOperator {
+	return UpdateOperator{operator: "$setOnInsert", field: field, value: value}
+}
+
+func push(field Field, value any) UpdateOperator {
+	return UpdateOperator{operator: "$push", field: field, value: value}
+}
+
+func addToSet(field Field, value any) UpdateOperator {
+	return UpdateOperator{operator: "$addToSet", field: field, value: value}
+}
+
+func pop(field Field, position PopPosition) UpdateOperator {
+	return UpdateOperator{operator: "$pop", field: field, value: int(position)}
+}
+
+func pull(field Field, condition any) UpdateOperator {
+	return UpdateOperator{operator: "$pull", field: field, value: condition}
+}
+
+func pullAll(field Field, values []any) UpdateOperator {
+	return UpdateOperator{operator: "$pullAll", field: field, value: values}
+}
+
 type UpdateExpression struct {
 	value any
+	err   error
 }
 
 // Set replaces the value of the field with the specified value.
@@ -95,6 +228,12 @@ func (f Field) Set(value any) UpdateExpression {
 	return UpdateExpression{value: set(f, value)}
 }
 
+// SetOnInsert sets the value of the field only if an update with the option
+// upsert inserts a new document. Existing documents are not modified.
+func (f Field) SetOnInsert(value any) UpdateExpression {
+	return UpdateExpression{value: setOnInsert(f, value)}
+}
+
 // Inc increments the field by a specified value.
 func (f Field) Inc(value any) UpdateExpression {
 	return UpdateExpression{value: inc(f, value)}
@@ -137,67 +276,256 @@ func (f Field) Unset() UpdateExpression {
 	return UpdateExpression{value: unset(f)}
 }
 
-// CurrentDate sets the value of a field to the current date, either as a Date or
-// a timestamp.
+// DateType defines the BSON type of the value written by CurrentDate.
+type DateType string
+
+const (
+	// DateTypeDate writes the current date as BSON Date.
+	DateTypeDate = DateType("date")
+	// DateTypeTimestamp writes the current date as BSON timestamp.
+	DateTypeTimestamp = DateType("timestamp")
+)
+
+// CurrentDate sets the value of a field to the current date as a Date.
 func (f Field) CurrentDate() UpdateExpression {
 	return UpdateExpression{value: currentDate(f)}
 }
 
+// CurrentDateOfType sets the value of a field to the current date, either as a
+// Date (DateTypeDate) or a timestamp (DateTypeTimestamp). Other types are
+// reported as ErrInvalidOperand.
+func (f Field) CurrentDateOfType(dateType DateType) UpdateExpression {
+	switch dateType {
+	case DateTypeDate, DateTypeTimestamp:
+		return UpdateExpression{value: currentDateOfType(f, dateType)}
+	}
+	return UpdateExpression{value: currentDateOfType(f, dateType),
+		err: fmt.Errorf("%w: $currentDate of field %q requires type date or timestamp but got %q", ErrInvalidOperand, f, dateType)}
+}
+
+// BitAnd updates the field to the result of a bitwise "and" of its value and the
+// given integer.
+func (f Field) BitAnd(value any) UpdateExpression {
+	return bitExpression(f, "and", value)
+}
+
+// BitOr updates the field to the result of a bitwise "or" of its value and the
+// given integer.
+func (f Field) BitOr(value any) UpdateExpression {
+	return bitExpression(f, "or", value)
+}
+
+// BitXor updates the field to the result of a bitwise "xor" of its value and the
+// given integer.
+func (f Field) BitXor(value any) UpdateExpression {
+	return bitExpression(f, "xor", value)
+}
+
+func bitExpression(f Field, operation string, value any) UpdateExpression {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint8, uint16:
+		return UpdateExpression{value: bit(f, operation, value)}
+	}
+	return UpdateExpression{value: bit(f, operation, value),
+		err: fmt.Errorf("%w: $bit %s of field %q requires an integer but got %T", ErrInvalidOperand, operation, f, value)}
+}
+
+// PopPosition defines which element is removed from an array by Pop.
+type PopPosition int
+
+const (
+	// PopFirst removes the first element of an array.
+	PopFirst PopPosition = -1
+	// PopLast removes the last element of an array.
+	PopLast PopPosition = 1
+)
+
+// PushModifier modifies the behaviour of PushEach.
+type PushModifier struct {
+	key   string
+	value any
+}
+
+// PushSlice limits the number of array elements after the push. A positive
+// number keeps the first, a negative number the last elements.
+func PushSlice(n int) PushModifier {
+	return PushModifier{key: "$slice", value: n}
+}
+
+// PushSort sorts the elements of an array of scalar values after the push (1 for
+// ascending, -1 for descending order).
+func PushSort(order int) PushModifier {
+	return PushModifier{key: "$sort", value: order}
+}
+
+// PushSortBy sorts the embedded documents of an array after the push. Like
+// ArrayElemMatchExpression the fields of the generated filter type for the array
+// (e.g. 'Review.Date') can be used.
+func PushSortBy(sort Sort) PushModifier {
+	return PushModifier{key: "$sort", value: sort}
+}
+
+// PushPosition defines the position in the array at which the elements are
+// inserted. A negative number counts from the end of the array.
+func PushPosition(position int) PushModifier {
+	return PushModifier{key: "$position", value: position}
+}
+
+// Push appends the given value(s) to the array.
+func (f ArrayField) Push(values ...any) UpdateExpression {
+	if len(values) == 1 {
+		return UpdateExpression{value: push(Field(f), values[0])}
+	}
+	return f.PushEach(values)
+}
+
+// PushEach appends the given values to the array. The modifiers define where the
+// values are inserted and how the array is sorted and sliced afterwards.
+func (f ArrayField) PushEach(values []any, modifiers ...PushModifier) UpdateExpression {
+	each := bson.D{{"$each", arrayValues(values)}}
+	for _, modifier := range modifiers {
+		if sort, ok := modifier.value.(Sort); ok {
+			relative, err := sort.relativeTo(string(f))
+			if err != nil {
+				return UpdateExpression{value: push(Field(f), nil), err: err}
+			}
+			modifier.value = relative
+		}
+		each = append(each, bson.E{Key: modifier.key, Value: modifier.value})
+	}
+	return UpdateExpression{value: push(Field(f), each)}
+}
+
+// AddToSet adds the given value(s) to the array unless they are already present.
+func (f ArrayField) AddToSet(values ...any) UpdateExpression {
+	if len(values) == 1 {
+		return UpdateExpression{value: addToSet(Field(f), values[0])}
+	}
+	return UpdateExpression{value: addToSet(Field(f), bson.D{{"$each", arrayValues(values)}})}
+}
+
+// Pop removes the first or last element of the array.
+func (f ArrayField) Pop(position PopPosition) UpdateExpression {
+	return UpdateExpression{value: pop(Field(f), position)}
+}
+
+// Pull removes all elements of an array of scalar values which satisfy the given
+// operators, e.g. Pull(Gte(6)). At least one operator is required.
+func (f ArrayField) Pull(operators ...QueryOperator) UpdateExpression {
+	if len(operators) == 0 {
+		return UpdateExpression{value: pull(Field(f), bson.D{}), err: &FilterError{Path: string(f), Operator: "$pull", Err: ErrInvalidOperand}}
+	}
+	return UpdateExpression{value: pull(Field(f), queryOperatorsToBSON(operators))}
+}
+
+// PullExpression removes all embedded documents of the array which satisfy all
+// the given expressions. Like ArrayElemMatchExpression the fields of the
+// generated filter type for the array can be used directly. At least one
+// expression is required.
+func (f ArrayField) PullExpression(expressions ...Expression) UpdateExpression {
+	if len(expressions) == 0 {
+		return UpdateExpression{value: pull(Field(f), bson.D{}), err: &FilterError{Path: string(f), Operator: "$pull", Err: ErrInvalidOperand}}
+	}
+	var relative []Expression
+	for _, expression := range expressions {
+		rewritten, err := expression.relativeTo(string(f), "")
+		if err != nil {
+			return UpdateExpression{value: pull(Field(f), nil), err: err}
+		}
+		relative = append(relative, rewritten)
+	}
+	for _, expression := range relative {
+		if err := expression.Validate(); err != nil {
+			return UpdateExpression{value: pull(Field(f), nil), err: err}
+		}
+	}
+	return UpdateExpression{value: pull(Field(f), expressionsToDocument(relative))}
+}
+
+// PullAll removes all instances of the given values from the array.
+func (f ArrayField) PullAll(values ...any) UpdateExpression {
+	return UpdateExpression{value: pullAll(Field(f), arrayValues(values))}
+}
+
+func arrayValues(values []any) []any {
+	if values == nil {
+		return []any{}
+	}
+	return values
+}
+
+// MarshalBSON serializes the UpdateExpression to BSON data.
 func (ue UpdateExpression) MarshalBSON() ([]byte, error) {
+	if ue.err != nil {
+		return nil, ue.err
+	}
 	data := ue.bsonD()
 	return bson.Marshal(data)
 }
 
+// And combines the update expressions into one update. Fields of the same update
+// operator are merged into a single operator document. The values of Push and
+// AddToSet for the same array are merged into one "$each" (unless modifiers are
+// used). If a path is modified more than once (or together with one of its
+// parents) an ErrUpdateConflict is reported.
 func (ue UpdateExpression) And(ue2 ...UpdateExpression) UpdateExpression {
 	var all []any
-	all = append(all, ue.value)
-	for _, upex := range ue2 {
+	err := ue.err
+	for _, upex := range append([]UpdateExpression{ue}, ue2...) {
+		if err == nil {
+			err = upex.err
+		}
+		if ce, ok := upex.value.(expressionCollector); ok {
+			all = append(all, ce.value...)
+			continue
+		}
 		all = append(all, upex.value)
 	}
 
-	return UpdateExpression{value: expressionCollector{value: all}}
+	ce := expressionCollector{value: all}
+	if err == nil {
+		fuo := fullUpdateOperator{}
+		for _, uo := range ce.updateOperators() {
+			if err = fuo.add(uo); err != nil {
+				break
+			}
+		}
+	}
+	return UpdateExpression{value: ce, err: err}
 }
 
 type expressionCollector struct {
 	value []any
 }
 
+func (ce expressionCollector) updateOperators() []UpdateOperator {
+	var operators []UpdateOperator
+	for _, val := range ce.value {
+		if uo, ok := val.(UpdateOperator); ok {
+			operators = append(operators, uo)
+		}
+	}
+	return operators
+}
+
 func (ue UpdateExpression) bsonD() bson.D {
-	var returnValue bson.D
 	switch ue.value.(type) {
 	case expressionCollector:
-		ce := ue.value.(expressionCollector)
 		fuo := fullUpdateOperator{}
-		for _, val := range ce.value {
-			uo, ok := val.(UpdateOperator)
-			if !ok {
-				log.Println("not an update operator")
-				continue
-			}
-
-			fuo[uo.operator] = append(fuo[uo.operator], uo.bsonWithoutOperator())
+		for _, uo := range ue.value.(expressionCollector).updateOperators() {
+			// conflicts are reported by And
+			_ = fuo.add(uo)
 		}
-
-		returnValue = fuo.bson()
+		return fuo.bson()
 	case UpdateOperator:
-		uo := ue.value.(UpdateOperator)
-		returnValue = uo.bson()
-	default:
-		// TODO: check default value
-		// is there a reasonable default value?? maybe it's better to not update as default..
-		returnValue = bson.D{{"$noop", ue.value}}
+		return ue.value.(UpdateOperator).bson()
 	}
-
-	log.Println(returnValue)
-	return returnValue
+	// TODO: check default value
+	// is there a reasonable default value?? maybe it's better to not update as default..
+	return bson.D{{"$noop", ue.value}}
 }
 
+// String returns the UpdateExpression as Go bson.D literal (see StyleGo).
 func (ue UpdateExpression) String() string {
-
-	data := ue.bsonD()
-	bytes, _ := bson.Marshal(data)
-	mapdata := make(map[string]any)
-	_ = bson.Unmarshal(bytes, &mapdata)
-
-	return mapToString(mapdata)
+	return ue.Render(StyleGo)
 }
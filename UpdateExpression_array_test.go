@@ -0,0 +1,206 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleArrayField_PushEach() {
+
+	expression := Listing.Amenities.PushEach([]any{"Pool", "Sauna"}, PushPosition(0), PushSlice(20))
+
+	fmt.Println(expression)
+	// Output: bson.D{{"$push", bson.D{{"amenities", bson.D{{"$each", bson.A{"Pool", "Sauna"}},{"$position", 0},{"$slice", 20}}}}}}
+
+}
+
+func ExampleArrayField_Pull() {
+
+	expression := Listing.Amenities.Pull(In("Iron", "Heating")).And(Listing.Name.Set("Horst"))
+
+	fmt.Println(expression)
+	// Output: bson.D{{"$pull", bson.D{{"amenities", bson.D{{"$in", bson.A{"Iron", "Heating"}}}}}},{"$set", bson.D{{"name", "Horst"}}}}
+
+}
+
+var arrayUpdateTestData = []struct {
+	testName string
+	update   UpdateExpression
+	expected bson.D
+}{
+	{
+		"push single value",
+		Listing.Amenities.Push("Pool"),
+		bson.D{{"$push", bson.D{{"amenities", "Pool"}}}},
+	},
+	{
+		"push multiple values",
+		Listing.Amenities.Push("Pool", "Sauna"),
+		bson.D{{"$push", bson.D{{"amenities", bson.D{{"$each", bson.A{"Pool", "Sauna"}}}}}}},
+	},
+	{
+		"push with sort of embedded documents",
+		Listing.Reviews.PushEach([]any{bson.D{{"reviewer_name", "Milo"}}}, PushSortBy(Desc(Review.Date)), PushSlice(-10)),
+		bson.D{{"$push", bson.D{{"reviews", bson.D{
+			{"$each", bson.A{bson.D{{"reviewer_name", "Milo"}}}},
+			{"$sort", bson.D{{"date", -1}}},
+			{"$slice", -10},
+		}}}}},
+	},
+	{
+		"push with sort of positional fields",
+		Listing.Reviews.PushEach([]any{bson.D{{"reviewer_name", "Milo"}}}, PushSortBy(Desc(Review.Positional().Date).Asc(Review.AllPositional().ReviewerName))),
+		bson.D{{"$push", bson.D{{"reviews", bson.D{
+			{"$each", bson.A{bson.D{{"reviewer_name", "Milo"}}}},
+			{"$sort", bson.D{{"date", -1}, {"reviewer_name", 1}}},
+		}}}}},
+	},
+	{
+		"push with sort of scalar values",
+		Listing.Amenities.PushEach([]any{"Pool"}, PushSort(1)),
+		bson.D{{"$push", bson.D{{"amenities", bson.D{{"$each", bson.A{"Pool"}}, {"$sort", 1}}}}}},
+	},
+	{
+		"add to set",
+		Listing.Amenities.AddToSet("Pool"),
+		bson.D{{"$addToSet", bson.D{{"amenities", "Pool"}}}},
+	},
+	{
+		"add to set multiple values",
+		Listing.Amenities.AddToSet("Pool", "Sauna"),
+		bson.D{{"$addToSet", bson.D{{"amenities", bson.D{{"$each", bson.A{"Pool", "Sauna"}}}}}}},
+	},
+	{
+		"pop first",
+		Listing.Amenities.Pop(PopFirst),
+		bson.D{{"$pop", bson.D{{"amenities", -1}}}},
+	},
+	{
+		"pop last",
+		Listing.Amenities.Pop(PopLast),
+		bson.D{{"$pop", bson.D{{"amenities", 1}}}},
+	},
+	{
+		"pull with expression",
+		Listing.Reviews.PullExpression(Review.ReviewerName.Equals("Milo"), Review.Comments.Regex("^spam")),
+		bson.D{{"$pull", bson.D{{"reviews", bson.D{{"reviewer_name", "Milo"}, {"comments", bson.D{{"$regex", "^spam"}}}}}}}},
+	},
+	{
+		"pull with positional expression",
+		Listing.Reviews.PullExpression(Review.ElementNo(0).ReviewerName.Equals("Milo")),
+		bson.D{{"$pull", bson.D{{"reviews", bson.D{{"reviewer_name", "Milo"}}}}}},
+	},
+	{
+		"pull all",
+		Listing.Amenities.PullAll("Iron", "Heating"),
+		bson.D{{"$pullAll", bson.D{{"amenities", bson.A{"Iron", "Heating"}}}}},
+	},
+	{
+		"merged with and",
+		Listing.Amenities.Push("Pool").And(Listing.Reviews.Pop(PopLast), Listing.Images.PictureUrl.Set("x")).
+			And(ArrayField("host.host_verifications").Push("email")),
+		bson.D{
+			{"$push", bson.D{{"amenities", "Pool"}, {"host.host_verifications", "email"}}},
+			{"$pop", bson.D{{"reviews", 1}}},
+			{"$set", bson.D{{"images.picture_url", "x"}}},
+		},
+	},
+	{
+		"push to same array merged with and",
+		Listing.Amenities.Push("Pool").And(Listing.Amenities.Push("Sauna", "Wifi")),
+		bson.D{{"$push", bson.D{{"amenities", bson.D{{"$each", bson.A{"Pool", "Sauna", "Wifi"}}}}}}},
+	},
+	{
+		"add to same set merged with and",
+		Listing.Amenities.AddToSet("Pool").And(Listing.Amenities.AddToSet("Sauna")),
+		bson.D{{"$addToSet", bson.D{{"amenities", bson.D{{"$each", bson.A{"Pool", "Sauna"}}}}}}},
+	},
+}
+
+func TestArrayUpdateExpressions(t *testing.T) {
+
+	for _, datum := range arrayUpdateTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			actual, err := bson.Marshal(datum.update)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			//then
+			expected, _ := bson.Marshal(datum.expected)
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("expected %v but got %v", bson.Raw(expected), bson.Raw(actual))
+			}
+
+		})
+	}
+
+}
+
+var arrayUpdateErrorTestData = []struct {
+	testName string
+	update   UpdateExpression
+	expected error
+}{
+	{"invalid pull expression", Listing.Name.Set("Horst").And(Listing.Reviews.PullExpression(Review.ReviewerName.In())), ErrInvalidOperand},
+	{"pull without operators", Listing.Amenities.Pull(), ErrInvalidOperand},
+	{"pull without expressions", Listing.Reviews.PullExpression(), ErrInvalidOperand},
+	{"pull with field outside of array", Listing.Reviews.PullExpression(Listing.Name.Equals("x")), ErrInvalidFieldName},
+	{"push with sort by field outside of array", Listing.Reviews.PushEach([]any{"x"}, PushSortBy(Asc(Listing.Name))), ErrInvalidFieldName},
+	{"push with sort by array itself", Listing.Reviews.PushEach([]any{"x"}, PushSortBy(Asc(Listing.Reviews))), ErrInvalidFieldName},
+	{"push with modifiers to same array", Listing.Amenities.Push("Pool").And(Listing.Amenities.PushEach([]any{"Sauna"}, PushSlice(5))), ErrUpdateConflict},
+	{"push and pull of same array", Listing.Amenities.Push("Pool").And(Listing.Amenities.Pull(Equals("Sauna"))), ErrUpdateConflict},
+	{"set of same field", Listing.Name.Set("a").And(Listing.Name.Set("b")), ErrUpdateConflict},
+	{"set of parent field", Listing.Images.PictureUrl.Set("a").And(Field("images").Unset()), ErrUpdateConflict},
+	{"rename to updated field", Listing.Name.Rename(Field("title")).And(Field("title").Set("a")), ErrUpdateConflict},
+}
+
+func TestArrayUpdateExpressions_errors(t *testing.T) {
+
+	for _, datum := range arrayUpdateErrorTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := bson.Marshal(datum.update)
+
+			//then
+			if !errors.Is(err, datum.expected) {
+				t.Errorf("expected %v but got %v", datum.expected, err)
+			}
+
+		})
+	}
+
+}
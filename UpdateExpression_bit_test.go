@@ -0,0 +1,139 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleField_BitOr() {
+
+	expression := Field("flags").BitOr(4)
+
+	fmt.Println(expression)
+	// Output: bson.D{{"$bit", bson.D{{"flags", bson.D{{"or", 4}}}}}}
+
+}
+
+func ExampleField_CurrentDateOfType() {
+
+	expression := Listing.LastScraped.CurrentDateOfType(DateTypeTimestamp)
+
+	fmt.Println(expression)
+	// Output: bson.D{{"$currentDate", bson.D{{"last_scraped", bson.D{{"$type", "timestamp"}}}}}}
+
+}
+
+var bitUpdateTestData = []struct {
+	testName string
+	update   UpdateExpression
+	expected bson.D
+}{
+	{
+		"and",
+		Field("flags").BitAnd(int32(10)),
+		bson.D{{"$bit", bson.D{{"flags", bson.D{{"and", int32(10)}}}}}},
+	},
+	{
+		"xor with int64",
+		Field("flags").BitXor(int64(1)),
+		bson.D{{"$bit", bson.D{{"flags", bson.D{{"xor", int64(1)}}}}}},
+	},
+	{
+		"operations of the same field are merged",
+		Field("flags").BitAnd(12).And(Field("mask").BitOr(1), Field("flags").BitOr(1)),
+		bson.D{{"$bit", bson.D{{"flags", bson.D{{"and", 12}, {"or", 1}}}, {"mask", bson.D{{"or", 1}}}}}},
+	},
+	{
+		"current date",
+		Listing.LastScraped.CurrentDate(),
+		bson.D{{"$currentDate", bson.D{{"last_scraped", true}}}},
+	},
+	{
+		"current date as date",
+		Listing.LastScraped.CurrentDateOfType(DateTypeDate).And(Field("modified").CurrentDateOfType(DateTypeTimestamp)),
+		bson.D{{"$currentDate", bson.D{
+			{"last_scraped", bson.D{{"$type", "date"}}},
+			{"modified", bson.D{{"$type", "timestamp"}}},
+		}}},
+	},
+}
+
+func TestBitAndCurrentDateUpdateExpressions(t *testing.T) {
+
+	for _, datum := range bitUpdateTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			actual, err := bson.Marshal(datum.update)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			//then
+			expected, _ := bson.Marshal(datum.expected)
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("expected %v but got %v", bson.Raw(expected), bson.Raw(actual))
+			}
+
+		})
+	}
+
+}
+
+var bitUpdateErrorTestData = []struct {
+	testName string
+	update   UpdateExpression
+	expected error
+}{
+	{"not an integer", Listing.Name.Set("a").And(Field("flags").BitAnd(1.5)), ErrInvalidOperand},
+	{"same operation twice", Field("flags").BitAnd(1).And(Field("flags").BitAnd(2)), ErrUpdateConflict},
+	{"unknown date type", Listing.LastScraped.CurrentDateOfType(DateType("foo")), ErrInvalidOperand},
+}
+
+func TestBitAndCurrentDateUpdateExpressions_errors(t *testing.T) {
+
+	for _, datum := range bitUpdateErrorTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, err := bson.Marshal(datum.update)
+
+			//then
+			if !errors.Is(err, datum.expected) {
+				t.Errorf("expected %v but got %v", datum.expected, err)
+			}
+
+		})
+	}
+
+}
@@ -65,6 +65,13 @@ var updateTestData = []struct {
 		Listing.LastScraped.CurrentDate(),
 		1,
 	},
+	{"push",
+		Listing.Amenities.ArrayContainsAll("mongo-query"),
+		0,
+		Listing.ListingUrl.Equals("https://www.airbnb.com/rooms/10009999"),
+		Listing.Amenities.Push("mongo-query"),
+		1,
+	},
 }
 
 func TestUpdateExpressions(t *testing.T) {
@@ -0,0 +1,152 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ErrEmptyStage is returned if a stage of an UpdatePipeline (or the pipeline
+// itself) is empty.
+var ErrEmptyStage = errors.New("empty update pipeline stage")
+
+// Assignment assigns a value to a field within a "$set" stage of an
+// UpdatePipeline.
+type Assignment struct {
+	field string
+	value any
+}
+
+// Assign returns an Assignment of the given value to the field. The value can be
+// an AggregationExpression, a field (Field, ArrayField, TypedField or
+// TypedArrayField which is used as reference to the value of the field) or a
+// plain value. Strings starting with "$" are interpreted as field
+// references by MongoDB - use Literal to assign them as value.
+func (f Field) Assign(value any) Assignment {
+	return Assignment{field: string(f), value: value}
+}
+
+// Assign returns an Assignment of the given value to the array field (see
+// Field.Assign).
+func (f ArrayField) Assign(value any) Assignment {
+	return Assignment{field: string(f), value: value}
+}
+
+// UpdatePipeline is an update which consists of aggregation pipeline stages. In
+// contrast to an UpdateExpression the new values can be computed from the
+// values of other fields of the document, e.g.
+//
+//	UpdatePipeline{}.Set(Field("total").Assign(Field("price").Ref().Multiply(Field("quantity"))))
+//
+// The stages are executed in the order they were added. All assignments of a
+// single "$set" stage are computed from the document before the stage.
+//
+// The UpdatePipeline can be passed as update to UpdateOne and UpdateMany of the
+// MongoDB API.
+type UpdatePipeline struct {
+	stages []bson.D
+	err    error
+}
+
+// Set adds a "$set" stage with the given assignments.
+func (p UpdatePipeline) Set(assignments ...Assignment) UpdatePipeline {
+	if len(assignments) == 0 {
+		return p.withError()
+	}
+	stage := bson.D{}
+	for _, assignment := range assignments {
+		stage = append(stage, bson.E{Key: assignment.field, Value: aggregationValue(assignment.value)})
+	}
+	return p.with(bson.D{{"$set", stage}})
+}
+
+// Unset adds an "$unset" stage which removes the given fields.
+func (p UpdatePipeline) Unset(fields ...FieldPath) UpdatePipeline {
+	if len(fields) == 0 {
+		return p.withError()
+	}
+	paths := bson.A{}
+	for _, field := range fields {
+		paths = append(paths, field.fieldPath())
+	}
+	return p.with(bson.D{{"$unset", paths}})
+}
+
+// ReplaceWith adds a "$replaceWith" stage which replaces the document with the
+// given value, e.g. an embedded document (Field) or a bson.D whose values are
+// aggregation expressions or field references.
+func (p UpdatePipeline) ReplaceWith(value any) UpdatePipeline {
+	return p.with(bson.D{{"$replaceWith", aggregationValue(value)}})
+}
+
+func (p UpdatePipeline) with(stage bson.D) UpdatePipeline {
+	stages := make([]bson.D, 0, len(p.stages)+1)
+	stages = append(stages, p.stages...)
+	return UpdatePipeline{stages: append(stages, stage), err: p.err}
+}
+
+func (p UpdatePipeline) withError() UpdatePipeline {
+	if p.err == nil {
+		p.err = ErrEmptyStage
+	}
+	return p
+}
+
+// Validate returns the first error of the pipeline, e.g. ErrEmptyStage.
+func (p UpdatePipeline) Validate() error {
+	if p.err != nil {
+		return p.err
+	}
+	if len(p.stages) == 0 {
+		return ErrEmptyStage
+	}
+	return nil
+}
+
+// MarshalBSONValue serializes the UpdatePipeline to a BSON array.
+func (p UpdatePipeline) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if err := p.Validate(); err != nil {
+		return 0, nil, err
+	}
+	return bson.MarshalValue(p.stages)
+}
+
+// String returns the stages of the UpdatePipeline as Go []bson.D literal.
+func (p UpdatePipeline) String() string {
+	// marshal and unmarshal to get the BSON types of all values
+	data, err := bson.Marshal(bson.D{{"stages", p.stages}})
+	if err != nil {
+		return fmt.Sprintf("%%!(ERROR=%v)", err)
+	}
+	var doc bson.D
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return fmt.Sprintf("%%!(ERROR=%v)", err)
+	}
+	return renderGo(doc[0].Value)
+}
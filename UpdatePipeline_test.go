@@ -0,0 +1,167 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func ExampleUpdatePipeline() {
+
+	pipeline := UpdatePipeline{}.
+		Set(Field("total").Assign(Listing.Price.Ref().Multiply(Field("quantity")))).
+		Unset(Field("quantity"))
+
+	fmt.Println(pipeline)
+	// Output: []bson.D{bson.D{{"$set", bson.D{{"total", bson.D{{"$multiply", bson.A{"$price", "$quantity"}}}}}}}, bson.D{{"$unset", bson.A{"quantity"}}}}
+
+}
+
+var updatePipelineTestData = []struct {
+	testName string
+	pipeline UpdatePipeline
+	expected bson.A
+}{
+	{
+		"set with field reference",
+		UpdatePipeline{}.Set(Field("copy").Assign(Listing.Name), Field("fee").Assign(IfNull(Listing.CleaningFee, 0))),
+		bson.A{bson.D{{"$set", bson.D{{"copy", "$name"}, {"fee", bson.D{{"$ifNull", bson.A{"$cleaning_fee", 0}}}}}}}},
+	},
+	{
+		"set with typed field reference",
+		UpdatePipeline{}.Set(typedListing.Name.Assign(Concat(TypedField[string]("first"), " ", typedListing.Name)),
+			Field("tags").Assign(typedListing.Amenities)),
+		bson.A{bson.D{{"$set", bson.D{{"name", bson.D{{"$concat", bson.A{"$first", " ", "$name"}}}}, {"tags", "$amenities"}}}}},
+	},
+	{
+		"multiple set stages",
+		UpdatePipeline{}.Set(Field("a").Assign(1)).Set(Field("b").Assign(Field("a").Ref().Add(1))),
+		bson.A{bson.D{{"$set", bson.D{{"a", 1}}}}, bson.D{{"$set", bson.D{{"b", bson.D{{"$add", bson.A{"$a", 1}}}}}}}},
+	},
+	{
+		"literal",
+		UpdatePipeline{}.Set(Listing.Name.Assign(Literal("$5"))),
+		bson.A{bson.D{{"$set", bson.D{{"name", bson.D{{"$literal", "$5"}}}}}}},
+	},
+	{
+		"unset",
+		UpdatePipeline{}.Unset(Listing.Name, Listing.Reviews),
+		bson.A{bson.D{{"$unset", bson.A{"name", "reviews"}}}},
+	},
+	{
+		"replace with",
+		UpdatePipeline{}.ReplaceWith(bson.D{{"name", Listing.Name}, {"country", Listing.Address.Country}}),
+		bson.A{bson.D{{"$replaceWith", bson.D{{"name", "$name"}, {"country", "$address.country"}}}}},
+	},
+}
+
+func TestUpdatePipeline_MarshalBSONValue(t *testing.T) {
+
+	for _, datum := range updatePipelineTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			_, actual, err := bson.MarshalValue(datum.pipeline)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			//then
+			_, expected, _ := bson.MarshalValue(datum.expected)
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("expected %v but got %v", expected, actual)
+			}
+
+		})
+	}
+
+}
+
+func TestUpdatePipeline_emptyStage(t *testing.T) {
+
+	for name, pipeline := range map[string]UpdatePipeline{
+		"empty pipeline": {},
+		"empty set":      UpdatePipeline{}.Set(),
+		"empty unset":    UpdatePipeline{}.Unset().Set(Field("a").Assign(1)),
+	} {
+		t.Run(name, func(t *testing.T) {
+
+			//when
+			_, _, err := bson.MarshalValue(pipeline)
+
+			//then
+			if !errors.Is(err, ErrEmptyStage) {
+				t.Errorf("expected ErrEmptyStage but got %v", err)
+			}
+
+		})
+	}
+
+}
+
+func TestUpdatePipeline_UpdateOne(t *testing.T) {
+
+	//given
+	err := cloneCollection(updateCollectionName)
+	if err != nil {
+		t.Fatalf("could not clone collection for testing %v", err)
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dbConnectionStringForTesting))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+	collection := client.Database("airbnb").Collection(updateCollectionName)
+
+	filter := Listing.ListingUrl.Equals("https://www.airbnb.com/rooms/10009999")
+	pipeline := UpdatePipeline{}.Set(Listing.Name.Assign(Concat(Listing.Name, " (", Listing.Address.Country, ")")))
+
+	//when
+	_, err = collection.UpdateOne(ctx, filter, pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//then
+	result, err := query[ListingAndReview](updateCollectionName, Listing.Name.Equals("Horto flat with small garden (Brazil)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 {
+		t.Errorf("expected 1 updated document but got %d", len(result))
+	}
+
+}
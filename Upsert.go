@@ -0,0 +1,122 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUpsertConflict is returned by CheckUpsert if the filter and the update of an
+// upsert use the same path.
+var ErrUpsertConflict = errors.New("filter and update of upsert use the same path")
+
+// EqualityFields returns the fields of all equality conditions of the Expression
+// which are used at top level or within "$and". If an update with the option
+// upsert inserts a new document, MongoDB copies these fields into the document.
+func (e Expression) EqualityFields() bson.D {
+	fields := bson.D{}
+	switch e.value.(type) {
+	case LogicalOperator:
+		lo := e.value.(LogicalOperator)
+		if lo.operator == "$and" {
+			for _, expression := range lo.expressions {
+				fields = append(fields, expression.EqualityFields()...)
+			}
+		}
+	case QueryOperator:
+		if qo := e.value.(QueryOperator); qo.operator == "$eq" {
+			fields = append(fields, bson.E{Key: string(e.field), Value: qo.value})
+		}
+	case []QueryOperator:
+		for _, qo := range e.value.([]QueryOperator) {
+			if qo.operator == "$eq" {
+				fields = append(fields, bson.E{Key: string(e.field), Value: qo.value})
+			}
+		}
+	case []Expression:
+		fields = append(fields, bson.E{Key: string(e.field), Value: expressionsToBSON(e.value.([]Expression))})
+	case textSearch, AggregationExpression, Schema, primitive.Regex:
+	default:
+		if e.field != "" {
+			fields = append(fields, bson.E{Key: string(e.field), Value: e.value})
+		}
+	}
+	return fields
+}
+
+// CheckUpsert returns an error wrapping ErrUpsertConflict if the update modifies
+// a path (or a parent or child of it) which is copied from the filter into the
+// inserted document (see EqualityFields). MongoDB either rejects such an upsert
+// or the update silently overwrites the value of the filter.
+func CheckUpsert(filter Expression, update UpdateExpression) error {
+	var conflicts []string
+	for _, field := range filter.EqualityFields() {
+		for _, path := range update.paths() {
+			if overlappingPaths(field.Key, path) {
+				conflicts = append(conflicts, fmt.Sprintf("%q (%q)", field.Key, path))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrUpsertConflict, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// paths returns all paths modified by the UpdateExpression.
+func (ue UpdateExpression) paths() []string {
+	var operators []UpdateOperator
+	switch ue.value.(type) {
+	case expressionCollector:
+		operators = ue.value.(expressionCollector).updateOperators()
+	case UpdateOperator:
+		operators = []UpdateOperator{ue.value.(UpdateOperator)}
+	}
+
+	var paths []string
+	for _, operator := range operators {
+		paths = append(paths, operator.paths()...)
+	}
+	return paths
+}
+
+// overlappingPaths reports if both paths are equal or one of them is the parent
+// of the other. Positional operators ("$", "$[]", "$[<identifier>]") end a path.
+func overlappingPaths(a, b string) bool {
+	a, b = withoutPositional(a), withoutPositional(b)
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+func withoutPositional(path string) string {
+	if i := strings.Index(path, ".$"); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
@@ -0,0 +1,122 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleField_SetOnInsert() {
+
+	expression := Listing.Name.Set("Horst").And(Listing.NumberOfReviews.SetOnInsert(0))
+
+	fmt.Println(expression)
+	// Output: bson.D{{"$set", bson.D{{"name", "Horst"}}},{"$setOnInsert", bson.D{{"number_of_reviews", 0}}}}
+
+}
+
+func ExampleExpression_EqualityFields() {
+
+	filter := Listing.ListingUrl.Equals("https://www.airbnb.com/rooms/10009999").
+		And(Listing.Bedrooms.Gt(1), Listing.Address.Country.Equals("Brazil"))
+
+	fmt.Println(filter.EqualityFields())
+	// Output: [{listing_url https://www.airbnb.com/rooms/10009999} {address.country Brazil}]
+
+}
+
+var equalityFieldsTestData = []struct {
+	testName string
+	filter   Expression
+	expected bson.D
+}{
+	{"equals", Listing.Name.Equals("a"), bson.D{{"name", "a"}}},
+	{"eq operator", Listing.Name.Where(Equals("a"), Ne("b")), bson.D{{"name", "a"}}},
+	{"nested and", Listing.Name.Equals("a").And(Listing.Bedrooms.Equals(1).And(Listing.Price.Equals(2))),
+		bson.D{{"name", "a"}, {"bedrooms", 1}, {"price", 2}}},
+	{"or is ignored", Listing.Name.Equals("a").Or(Listing.Name.Equals("b")), bson.D{}},
+	{"operators are ignored", Listing.Name.Regex("^a").And(Listing.Bedrooms.In(1, 2)), bson.D{}},
+	{"exact array", Listing.Amenities.ArrayContainsExact("Wifi"), bson.D{{"amenities", bson.A{"Wifi"}}}},
+}
+
+func TestExpression_EqualityFields(t *testing.T) {
+
+	for _, datum := range equalityFieldsTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			actual, _ := bson.Marshal(datum.filter.EqualityFields())
+
+			//then
+			expected, _ := bson.Marshal(datum.expected)
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("expected %v but got %v", bson.Raw(expected), bson.Raw(actual))
+			}
+
+		})
+	}
+
+}
+
+var checkUpsertTestData = []struct {
+	testName string
+	filter   Expression
+	update   UpdateExpression
+	conflict bool
+}{
+	{"different paths", Listing.ListingUrl.Equals("x"), Listing.Name.Set("a").And(Listing.Bedrooms.SetOnInsert(1)), false},
+	{"same path", Listing.ListingUrl.Equals("x"), Listing.Name.Set("a").And(Listing.ListingUrl.SetOnInsert("y")), true},
+	{"parent path", Listing.Address.Country.Equals("Brazil"), Field("address").Set(bson.D{}), true},
+	{"child path", Field("address").Equals(bson.D{}), Listing.Address.Country.Set("Brazil"), true},
+	{"similar prefix", Listing.Name.Equals("a"), Field("names").Set("b"), false},
+	{"positional path", Review.ReviewerName.Equals("Milo"), Review.Positional().Comments.Set("b"), true},
+	{"renamed path", Listing.Name.Equals("a"), Field("title").Rename(Listing.Name), true},
+	{"only equality conditions", Listing.Name.Gt("a"), Listing.Name.Set("b"), false},
+}
+
+func TestCheckUpsert(t *testing.T) {
+
+	for _, datum := range checkUpsertTestData {
+
+		t.Run(datum.testName, func(t *testing.T) {
+
+			//when
+			err := CheckUpsert(datum.filter, datum.update)
+
+			//then
+			if errors.Is(err, ErrUpsertConflict) != datum.conflict {
+				t.Errorf("expected conflict %v but got %v", datum.conflict, err)
+			}
+
+		})
+	}
+
+}
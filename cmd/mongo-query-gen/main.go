@@ -37,6 +37,8 @@ func main() {
 	inFile := flag.String("in", "", "path to file with Golang structs")
 	outDirectory := flag.String("outDir", "", "path to output directory - a subdirectory \"filter\" will be generated automatically")
 	explicitStructs := flag.String("only", "", "list of struct names - only given struct names will be used for code generation")
+	typed := flag.Bool("typed", false, "generate typed fields (mq.TypedField[T]) for all fields with a known Go type")
+	withSchema := flag.Bool("schema", false, "additionally generate a JSON schema file for each struct")
 
 	flag.Parse()
 
@@ -74,10 +76,13 @@ func main() {
 	}
 
 	writerType := internal.StructWriter
+	if *typed {
+		writerType = internal.TypedStructWriter
+	}
 	for _, mongoDbStruct := range mongoDbStructs {
 		//out := os.Stdout
 		outFile := fmt.Sprintf("%s/%sFilter.go", outDir, mongoDbStruct.Name)
-		out, err := os.OpenFile(outFile, os.O_CREATE|os.O_RDWR, 0644)
+		out, err := os.OpenFile(outFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -88,6 +93,21 @@ func main() {
 		}
 
 		out.Close()
+
+		if *withSchema {
+			schemaFile := fmt.Sprintf("%s/%sSchema.json", outDir, mongoDbStruct.Name)
+			out, err := os.OpenFile(schemaFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = internal.WriteJSONSchema(mongoDbStruct, out)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			out.Close()
+		}
 	}
 
 	fmt.Println("generation of mongodb-queries finished.")
@@ -263,14 +263,38 @@ var Reviews = ReviewsFilter{
 	Comments:     mq.Field("reviews.comments"),
 }
 
+// ElementNo returns the fields of the array element with the given index.
 func (r ReviewsFilter) ElementNo(i int) ReviewsFilter {
-	prefix := "reviews." + strconv.Itoa(i)
+	return r.element(strconv.Itoa(i))
+}
+
+// Positional returns the fields of the first array element which matches
+// the query (positional operator "$").
+func (r ReviewsFilter) Positional() ReviewsFilter {
+	return r.element("$")
+}
+
+// AllPositional returns the fields of all array elements (all positional
+// operator "$[]").
+func (r ReviewsFilter) AllPositional() ReviewsFilter {
+	return r.element("$[]")
+}
+
+// FilteredPositional returns the fields of all array elements which match
+// the array filter with the given identifier (filtered positional operator
+// "$[<identifier>]").
+func (r ReviewsFilter) FilteredPositional(identifier string) ReviewsFilter {
+	return r.element("$[" + identifier + "]")
+}
+
+func (r ReviewsFilter) element(segment string) ReviewsFilter {
+	prefix := "reviews." + segment
 	return ReviewsFilter{
-		Id:           mq.Field(prefix + "_id"),
-		Date:         mq.Field(prefix + "date"),
-		ListingId:    mq.Field(prefix + "listing_id"),
-		ReviewerId:   mq.Field(prefix + "reviewer_id"),
-		ReviewerName: mq.Field(prefix + "reviewer_name"),
-		Comments:     mq.Field(prefix + "comments"),
+		Id:           mq.Field(prefix + "._id"),
+		Date:         mq.Field(prefix + ".date"),
+		ListingId:    mq.Field(prefix + ".listing_id"),
+		ReviewerId:   mq.Field(prefix + ".reviewer_id"),
+		ReviewerName: mq.Field(prefix + ".reviewer_name"),
+		Comments:     mq.Field(prefix + ".comments"),
 	}
 }
@@ -65,6 +65,35 @@ func (mds MongoDBStruct) HasStructsInArray() bool {
 	return false
 }
 
+var referenceableTypes = map[string]bool{
+	"string": true, "bool": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+	"time.Time": true,
+}
+
+// GoType returns the Go type of the field (or of the elements of an array field)
+// if it can be referenced from the generated filter package. Otherwise, e.g. for
+// types defined in the parsed file, an empty string is returned.
+func (f Field) GoType() string {
+	typeName := strings.TrimPrefix(f.TypeName, "*")
+	// []byte is written as binary data and not as array
+	if typeName == "[]byte" {
+		return typeName
+	}
+	if f.ArrayType {
+		if f.StructType != nil || !strings.HasPrefix(typeName, "[]") {
+			return ""
+		}
+		typeName = strings.TrimPrefix(strings.TrimPrefix(typeName, "[]"), "*")
+	}
+	if referenceableTypes[typeName] || strings.HasPrefix(typeName, "primitive.") {
+		return typeName
+	}
+	return ""
+}
+
 func ParseFile(input io.Reader, explicitStructs string) ([]*MongoDBStruct, error) {
 	fs := token.NewFileSet()
 	file, err := parser.ParseFile(fs, "", input, 0)
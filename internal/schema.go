@@ -0,0 +1,157 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package internal
+
+import (
+	mq "github.com/sourcefellows/mongo-query"
+	"go.mongodb.org/mongo-driver/bson"
+	"io"
+	"strings"
+)
+
+var bsonTypesByTypeName = map[string][]mq.BsonType{
+	"string":               {mq.BsonTypeString},
+	"bool":                 {mq.BsonTypeBool},
+	"int":                  {mq.BsonTypeInt, mq.BsonTypeLong},
+	"int8":                 {mq.BsonTypeInt},
+	"int16":                {mq.BsonTypeInt},
+	"int32":                {mq.BsonTypeInt},
+	"uint8":                {mq.BsonTypeInt},
+	"uint16":               {mq.BsonTypeInt},
+	"int64":                {mq.BsonTypeLong},
+	"uint32":               {mq.BsonTypeLong},
+	"float32":              {mq.BsonTypeDouble},
+	"float64":              {mq.BsonTypeDouble},
+	"[]byte":               {mq.BsonTypeBinData},
+	"time.Time":            {mq.BsonTypeDate},
+	"primitive.DateTime":   {mq.BsonTypeDate},
+	"primitive.Decimal128": {mq.BsonTypeDecimal},
+	"primitive.ObjectID":   {mq.BsonTypeObjectId},
+	"primitive.Binary":     {mq.BsonTypeBinData},
+	"primitive.Timestamp":  {mq.BsonTypeTimestamp},
+	"primitive.Regex":      {mq.BsonTypeRegex},
+	"primitive.D":          {mq.BsonTypeObject},
+	"primitive.M":          {mq.BsonTypeObject},
+	"bson.D":               {mq.BsonTypeObject},
+	"bson.M":               {mq.BsonTypeObject},
+	"primitive.A":          {mq.BsonTypeArray},
+	"bson.A":               {mq.BsonTypeArray},
+}
+
+// JSONSchema derives a JSON schema from the parsed struct. All fields which are
+// not tagged with "omitempty" are required. Because the driver writes nil slices
+// and maps as null, null is allowed for these fields. Fields with types which can
+// not be mapped to a BSON type are not restricted.
+func (mds MongoDBStruct) JSONSchema() mq.Schema {
+	schema := mq.Schema{
+		BsonType:   []mq.BsonType{mq.BsonTypeObject},
+		Properties: map[string]mq.Schema{},
+	}
+
+	for _, field := range mds.Fields {
+		name, omitEmpty := bsonName(field.BsonTag)
+		schema.Properties[name] = field.jsonSchema(!omitEmpty)
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	for _, nestedStruct := range mds.NestedStructs {
+		name, omitEmpty := bsonName(nestedStruct.BsonTag[strings.LastIndex(nestedStruct.BsonTag, ".")+1:])
+		schema.Properties[name] = nestedStruct.JSONSchema()
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func (f Field) jsonSchema(nullable bool) mq.Schema {
+	// []byte is written as binary data and not as array
+	if f.ArrayType && f.TypeName != "[]byte" {
+		items := typeSchema(strings.TrimPrefix(f.TypeName, "[]"), false)
+		if f.StructType != nil {
+			items = f.StructType.JSONSchema()
+		}
+		bsonTypes := []mq.BsonType{mq.BsonTypeArray}
+		if nullable && strings.HasPrefix(f.TypeName, "[]") {
+			bsonTypes = append(bsonTypes, mq.BsonTypeNull)
+		}
+		return mq.Schema{BsonType: bsonTypes, Items: &items}
+	}
+	return typeSchema(f.TypeName, nullable)
+}
+
+// typeSchema returns the schema of the given type. Null is allowed for pointers
+// and, if nullable is true, for types whose nil value is written as null.
+func typeSchema(typeName string, nullable bool) mq.Schema {
+	pointer := strings.HasPrefix(typeName, "*")
+	typeName = strings.TrimPrefix(typeName, "*")
+	bsonTypes, ok := bsonTypesByTypeName[typeName]
+	if !ok && strings.HasPrefix(typeName, "map[") {
+		bsonTypes, ok = []mq.BsonType{mq.BsonTypeObject}, true
+	}
+	if !ok {
+		return mq.Schema{}
+	}
+	bsonTypes = append([]mq.BsonType{}, bsonTypes...)
+	if pointer || nullable && nilable(typeName) {
+		bsonTypes = append(bsonTypes, mq.BsonTypeNull)
+	}
+	return mq.Schema{BsonType: bsonTypes}
+}
+
+func nilable(typeName string) bool {
+	switch typeName {
+	case "bson.D", "bson.M", "bson.A", "primitive.D", "primitive.M", "primitive.A":
+		return true
+	}
+	return strings.HasPrefix(typeName, "[]") || strings.HasPrefix(typeName, "map[")
+}
+
+// bsonName returns the field name of the given BSON tag and if the field is
+// tagged with "omitempty".
+func bsonName(bsonTag string) (string, bool) {
+	parts := strings.Split(bsonTag, ",")
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			return parts[0], true
+		}
+	}
+	return parts[0], false
+}
+
+// WriteJSONSchema writes the JSON schema derived from the given struct as
+// (relaxed) Extended JSON, so it can be used to install a collection validator.
+func WriteJSONSchema(dbStruct *MongoDBStruct, writer io.Writer) error {
+	data, err := bson.MarshalExtJSONIndent(dbStruct.JSONSchema(), false, false, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	return err
+}
@@ -0,0 +1,97 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package internal
+
+import (
+	mq "github.com/sourcefellows/mongo-query"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const schemaTestFile = `package internal
+
+type Listing struct {
+	Name      string             ` + "`bson:\"name\"`" + `
+	Bedrooms  *int               ` + "`bson:\"bedrooms,omitempty\"`" + `
+	Scraped   primitive.DateTime ` + "`bson:\"last_scraped\"`" + `
+	Amenities []string           ` + "`bson:\"amenities\"`" + `
+	Custom    Unknown            ` + "`bson:\"custom\"`" + `
+	Picture   []byte             ` + "`bson:\"picture\"`" + `
+	Beds      int                ` + "`bson:\"beds\"`" + `
+	Ratings   map[string]int     ` + "`bson:\"ratings\"`" + `
+	Tags      []string           ` + "`bson:\"tags,omitempty\"`" + `
+	Extra     bson.M             ` + "`bson:\"extra\"`" + `
+	Reviews   []struct {
+		Comments string ` + "`bson:\"comments\"`" + `
+	} ` + "`bson:\"reviews\"`" + `
+	Images struct {
+		Url string ` + "`bson:\"url\"`" + `
+	} ` + "`bson:\"images\"`" + `
+}
+`
+
+func TestMongoDBStruct_JSONSchema(t *testing.T) {
+	//given
+	mongoDBStructs, err := ParseFile(strings.NewReader(schemaTestFile), "")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+
+	//when
+	schema := mongoDBStructs[0].JSONSchema()
+
+	//then
+	expected := mq.Schema{
+		BsonType: []mq.BsonType{mq.BsonTypeObject},
+		Required: []string{"name", "last_scraped", "amenities", "custom", "picture", "beds", "ratings", "extra", "reviews", "images"},
+		Properties: map[string]mq.Schema{
+			"name":         {BsonType: []mq.BsonType{mq.BsonTypeString}},
+			"bedrooms":     {BsonType: []mq.BsonType{mq.BsonTypeInt, mq.BsonTypeLong, mq.BsonTypeNull}},
+			"last_scraped": {BsonType: []mq.BsonType{mq.BsonTypeDate}},
+			"amenities":    {BsonType: []mq.BsonType{mq.BsonTypeArray, mq.BsonTypeNull}, Items: &mq.Schema{BsonType: []mq.BsonType{mq.BsonTypeString}}},
+			"custom":       {},
+			"picture":      {BsonType: []mq.BsonType{mq.BsonTypeBinData, mq.BsonTypeNull}},
+			"beds":         {BsonType: []mq.BsonType{mq.BsonTypeInt, mq.BsonTypeLong}},
+			"ratings":      {BsonType: []mq.BsonType{mq.BsonTypeObject, mq.BsonTypeNull}},
+			"tags":         {BsonType: []mq.BsonType{mq.BsonTypeArray}, Items: &mq.Schema{BsonType: []mq.BsonType{mq.BsonTypeString}}},
+			"extra":        {BsonType: []mq.BsonType{mq.BsonTypeObject, mq.BsonTypeNull}},
+			"reviews": {BsonType: []mq.BsonType{mq.BsonTypeArray, mq.BsonTypeNull}, Items: &mq.Schema{
+				BsonType:   []mq.BsonType{mq.BsonTypeObject},
+				Required:   []string{"comments"},
+				Properties: map[string]mq.Schema{"comments": {BsonType: []mq.BsonType{mq.BsonTypeString}}},
+			}},
+			"images": {
+				BsonType:   []mq.BsonType{mq.BsonTypeObject},
+				Required:   []string{"url"},
+				Properties: map[string]mq.Schema{"url": {BsonType: []mq.BsonType{mq.BsonTypeString}}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("expected schema %+v but got %+v", expected, schema)
+	}
+}
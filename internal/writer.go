@@ -35,15 +35,21 @@ import (
 
 type WriterType struct {
 	template string
+	typed    bool
 }
 
 var (
-	StructWriter = WriterType{templates.StructFilterTemplate}
+	StructWriter = WriterType{template: templates.StructFilterTemplate}
+	// TypedStructWriter generates TypedField and TypedArrayField types for
+	// all fields with a known Go type.
+	TypedStructWriter = WriterType{template: templates.StructFilterTemplate, typed: true}
 )
 
 func Write(dbStruct *MongoDBStruct, writerType WriterType, writer io.Writer) error {
 	funcs := template.FuncMap{
-		"toLower": toLower,
+		"toLower":   toLower,
+		"fieldType": fieldType(writerType.typed),
+		"imports":   imports(writerType.typed),
 	}
 
 	tmpl, err := template.New("type").
@@ -72,3 +78,63 @@ func Write(dbStruct *MongoDBStruct, writerType WriterType, writer io.Writer) err
 func toLower(input string) string {
 	return strings.ToLower(input)
 }
+
+// fieldType returns the filter type to use for a field. Typed fields are only
+// generated if the Go type of the field can be referenced in the filter package.
+func fieldType(typed bool) func(*Field) string {
+	return func(f *Field) string {
+		goType := ""
+		if typed {
+			goType = f.GoType()
+		}
+		switch {
+		case goType == "[]byte":
+			return "mq.TypedField[[]byte]"
+		case f.ArrayType && goType != "":
+			return "mq.TypedArrayField[" + goType + "]"
+		case f.ArrayType:
+			return "mq.ArrayField"
+		case goType != "":
+			return "mq.TypedField[" + goType + "]"
+		default:
+			return "mq.Field"
+		}
+	}
+}
+
+// imports returns the packages needed for the types of typed fields.
+func imports(typed bool) func(*MongoDBStruct) []string {
+	return func(dbStruct *MongoDBStruct) []string {
+		if !typed {
+			return nil
+		}
+		packages := make(map[string]bool)
+		dbStruct.collectImports(packages)
+
+		var imports []string
+		for _, pkg := range []string{"time", "go.mongodb.org/mongo-driver/bson/primitive"} {
+			if packages[pkg] {
+				imports = append(imports, pkg)
+			}
+		}
+		return imports
+	}
+}
+
+func (mds MongoDBStruct) collectImports(packages map[string]bool) {
+	for _, field := range mds.Fields {
+		goType := field.GoType()
+		switch {
+		case strings.HasPrefix(goType, "time."):
+			packages["time"] = true
+		case strings.HasPrefix(goType, "primitive."):
+			packages["go.mongodb.org/mongo-driver/bson/primitive"] = true
+		}
+		if field.StructType != nil {
+			field.StructType.collectImports(packages)
+		}
+	}
+	for _, nestedStruct := range mds.NestedStructs {
+		nestedStruct.collectImports(packages)
+	}
+}
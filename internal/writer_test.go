@@ -0,0 +1,159 @@
+/**
+ * MIT License
+ *
+ * Copyright (c) 2023 Source Fellows GmbH (https://www.source-fellows.com)
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package internal
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const typedTestFile = `package internal
+
+type Typed struct {
+	Name      string
+	Bedrooms  *int
+	Scraped   primitive.DateTime
+	Amenities []string
+	Custom    Unknown
+	Picture   []byte
+	Reviews   []struct {
+		Comments string
+	}
+}
+`
+
+func TestWrite_typed(t *testing.T) {
+	//given
+	mongoDBStructs, err := ParseFile(strings.NewReader(typedTestFile), "")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	var out bytes.Buffer
+
+	//when
+	err = Write(mongoDBStructs[0], TypedStructWriter, &out)
+
+	//then
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+
+	for _, expected := range []string{
+		`"go.mongodb.org/mongo-driver/bson/primitive"`,
+		`Name      mq.TypedField[string]`,
+		`Bedrooms  mq.TypedField[int]`,
+		`Scraped   mq.TypedField[primitive.DateTime]`,
+		`Amenities mq.TypedArrayField[string]`,
+		`Custom    mq.Field`,
+		`Picture   mq.TypedField[[]byte]`,
+		`Reviews   mq.ArrayField`,
+		`Comments: mq.TypedField[string](prefix + ".comments")`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected generated code to contain %s but got:\n%s", expected, out.String())
+		}
+	}
+}
+
+const typedUsageFile = `package filter
+
+import mq "github.com/sourcefellows/mongo-query"
+
+var (
+	_ = Typed.Name.Equals("a").And(Typed.Bedrooms.Gt(2), Typed.Amenities.ArrayContains("Wifi"))
+	_ = Typed.Picture.Equals([]byte{0x01}).Or(Typed.Picture.NotExists())
+	_ = mq.Inc(Typed.Bedrooms, 1).And(mq.BitOr(Typed.Bedrooms, 4), Typed.Amenities.Push("Pool"), Reviews.ElementNo(0).Comments.Set("b"))
+	_ = Typed.Reviews.ArrayElemMatchExpression(Reviews.Comments.Equals("c"))
+)
+`
+
+func TestWrite_typedCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling generated code is skipped in short mode")
+	}
+
+	//given
+	mongoDBStructs, err := ParseFile(strings.NewReader(typedTestFile), "")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	var out bytes.Buffer
+	if err = Write(mongoDBStructs[0], TypedStructWriter, &out); err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	// directories starting with "_" are ignored by "go build ./..."
+	dir, err := os.MkdirTemp(".", "_generated")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{"filter.go": out.String(), "usage.go": typedUsageFile}
+	for name, content := range files {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+	}
+
+	//when
+	output, err := exec.Command("go", "vet", "./"+filepath.ToSlash(dir)).CombinedOutput()
+
+	//then
+	if err != nil {
+		t.Errorf("generated code does not compile: %v\n%s\n%s", err, output, out.String())
+	}
+}
+
+func TestWrite_untyped(t *testing.T) {
+	//given
+	mongoDBStructs, err := ParseFile(strings.NewReader(typedTestFile), "")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	var out bytes.Buffer
+
+	//when
+	err = Write(mongoDBStructs[0], StructWriter, &out)
+
+	//then
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+
+	if strings.Contains(out.String(), "mq.Typed") {
+		t.Errorf("expected generated code without typed fields but got:\n%s", out.String())
+	}
+}
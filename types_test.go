@@ -30,13 +30,19 @@ import (
 )
 
 type ListingFilter struct {
-	ListingUrl  Field
-	Name        Field
-	Bedrooms    Field
-	Amenities   ArrayField
-	Images      ImagesFilter
-	Reviews     ArrayField
-	LastScraped Field
+	ListingUrl      Field
+	Name            Field
+	Bedrooms        Field
+	Bathrooms       Field
+	Amenities       ArrayField
+	Price           Field
+	CleaningFee     Field
+	Images          ImagesFilter
+	Address         AddressFilter
+	Reviews         ArrayField
+	LastScraped     Field
+	NumberOfReviews Field
+	ReviewsPerMonth Field
 }
 
 type ReviewFilter struct {
@@ -48,6 +54,11 @@ type ReviewFilter struct {
 	Comments     Field
 }
 
+type AddressFilter struct {
+	Country  Field
+	Location Field
+}
+
 type ImagesFilter struct {
 	ThumbnailUrl Field
 	MediumUrl    Field
@@ -56,18 +67,27 @@ type ImagesFilter struct {
 }
 
 var Listing = ListingFilter{
-	ListingUrl: Field("listing_url"),
-	Name:       Field("name"),
-	Bedrooms:   Field("bedrooms"),
-	Amenities:  ArrayField("amenities"),
+	ListingUrl:  Field("listing_url"),
+	Name:        Field("name"),
+	Bedrooms:    Field("bedrooms"),
+	Bathrooms:   Field("bathrooms"),
+	Amenities:   ArrayField("amenities"),
+	Price:       Field("price"),
+	CleaningFee: Field("cleaning_fee"),
 	Images: ImagesFilter{
 		ThumbnailUrl: Field("images.thumbnail_url"),
 		MediumUrl:    Field("images.medium_url"),
 		PictureUrl:   Field("images.picture_url"),
 		XlPictureUrl: Field("images.xl_picture_url"),
 	},
-	Reviews:     ArrayField("reviews"),
-	LastScraped: Field("last_scraped"),
+	Address: AddressFilter{
+		Country:  Field("address.country"),
+		Location: Field("address.location"),
+	},
+	Reviews:         ArrayField("reviews"),
+	LastScraped:     Field("last_scraped"),
+	NumberOfReviews: Field("number_of_reviews"),
+	ReviewsPerMonth: Field("reviews_per_month"),
 }
 
 var Review = ReviewFilter{
@@ -80,7 +100,23 @@ var Review = ReviewFilter{
 }
 
 func (r ReviewFilter) ElementNo(i int) ReviewFilter {
-	prefix := "reviews." + strconv.Itoa(i)
+	return r.element(strconv.Itoa(i))
+}
+
+func (r ReviewFilter) Positional() ReviewFilter {
+	return r.element("$")
+}
+
+func (r ReviewFilter) AllPositional() ReviewFilter {
+	return r.element("$[]")
+}
+
+func (r ReviewFilter) FilteredPositional(identifier string) ReviewFilter {
+	return r.element("$[" + identifier + "]")
+}
+
+func (r ReviewFilter) element(segment string) ReviewFilter {
+	prefix := "reviews." + segment
 	return ReviewFilter{
 		Id:           Field(prefix + "._id"),
 		Date:         Field(prefix + ".date"),